@@ -0,0 +1,46 @@
+/*
+createadmin creates the first ADMIN user, or promotes an existing one.
+
+	go run ./cmd/createadmin -email admin@example.com -name "Ops Admin"
+
+The password is read from ADMIN_PASSWORD so it stays out of shell
+history; it is only needed when the user does not exist yet.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+)
+
+func main() {
+	email := flag.String("email", "", "admin email (required)")
+	name := flag.String("name", "", "display name for a new user")
+	flag.Parse()
+
+	if *email == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config.LoadConfig()
+	database.Connect(config.AppConfig.DBDsn)
+	database.DB.AutoMigrate(&auth.User{})
+
+	user, created, err := auth.BootstrapAdmin(*name, *email, os.Getenv("ADMIN_PASSWORD"))
+	if err != nil {
+		log.Fatal("createadmin: ", err)
+	}
+
+	if created {
+		fmt.Println("Created admin", user.Email, user.ID)
+	} else {
+		fmt.Println("Admin", user.Email, user.ID, "is ready")
+	}
+}
@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
 	"fraud-detection-backend/internal/auth"
 	"fraud-detection-backend/internal/config"
 	"fraud-detection-backend/internal/database"
 	"fraud-detection-backend/internal/events"
+	"fraud-detection-backend/internal/fraud"
 	"fraud-detection-backend/internal/jobs"
 	"fraud-detection-backend/internal/logger"
+	"fraud-detection-backend/internal/mailer"
+	"fraud-detection-backend/internal/merchants"
 	"fraud-detection-backend/internal/notifications"
+	"fraud-detection-backend/internal/risklists"
 	"fraud-detection-backend/internal/router"
 	"fraud-detection-backend/internal/transactions"
+	"fraud-detection-backend/internal/webhooks"
 )
 
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	config.LoadConfig()
 	logger.InitLogger()
@@ -21,14 +36,77 @@ func main() {
 
 	database.DB.AutoMigrate(
 		&auth.User{},
+		&auth.Session{},
+		&auth.RefreshToken{},
+		&auth.RevokedToken{},
+		&auth.UserRevocation{},
+		&auth.LoginAttempt{},
+		&auth.StepUpChallenge{},
+		&auth.LoginThrottle{},
+		&auth.UserToken{},
+		&auth.ServiceAccount{},
+		&auth.APIKey{},
+		&auth.APIKeyRequest{},
+		&auth.MFAFactor{},
+		&auth.RecoveryCode{},
+		&auth.UserStatusChange{},
 		&transactions.Transaction{},
+		&transactions.TransactionTransition{},
+		&transactions.IdempotencyKey{},
+		&transactions.Reversal{},
 		&transactions.Device{},
+		&merchants.Merchant{},
+		&risklists.RiskListEntry{},
 		&notifications.Notification{},
+		&events.OutboxMessage{},
+		&events.DeadLetter{},
+		&webhooks.Endpoint{},
+		&webhooks.Delivery{},
+		&webhooks.DeliveryAttempt{},
 	)
 
-	events.InitRabbitMQ()
-	events.StartTransactionConsumer()
+	if err := auth.LoadKeys(); err != nil {
+		log.Fatal("Failed to load JWT keys: ", err)
+	}
+	if err := auth.LoadRevocations(); err != nil {
+		log.Fatal("Failed to load token revocations: ", err)
+	}
+
+	mailer.InitMailer()
+	events.InitBroker()
+	events.StartOutboxRelay()
+	events.StartTransactionConsumer(fraud.EvaluateTransaction)
+	events.StartDeadLetterConsumer()
+	webhooks.StartDispatcher()
+	webhooks.StartDeliveryWorker()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{
+		Addr:    ":" + config.AppConfig.ServerPort,
+		Handler: router.SetupRouter(),
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("HTTP server failed: ", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("🛑 Shutting down")
+
+	// Stop accepting requests first, then let in-flight evaluations finish.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("⚠️ HTTP shutdown:", err)
+	}
+	events.Shutdown(shutdownCtx)
+	webhooks.StopDeliveryWorker(shutdownCtx)
+	jobs.StopScheduler(shutdownCtx)
 
-	r := router.SetupRouter()
-	r.Run(":" + config.AppConfig.ServerPort)
+	log.Println("👋 Shutdown complete")
 }
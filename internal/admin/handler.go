@@ -1,11 +1,23 @@
 package admin
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/events"
+	"fraud-detection-backend/internal/merchants"
+	"fraud-detection-backend/internal/risklists"
+	"fraud-detection-backend/internal/transactions"
+	"fraud-detection-backend/internal/users"
+	"fraud-detection-backend/internal/webhooks"
 	"fraud-detection-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // GET /admin/transactions
@@ -41,3 +53,719 @@ func GetAuditLogsHandler(c *gin.Context) {
 	}
 	response.Success(c, "Audit logs fetched", data)
 }
+
+// GET /admin/transactions/:id/transitions
+func GetTransactionTransitionsHandler(c *gin.Context) {
+	data, err := transactions.FetchTransitions(c.Param("id"))
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch transaction transitions", err.Error())
+		return
+	}
+	response.Success(c, "Transaction transitions fetched", data)
+}
+
+// GET /admin/transactions/search?user_id=&status=&...&cursor=&limit=
+func SearchTransactionsHandler(c *gin.Context) {
+	filter, err := transactions.ParseSearchFilter(c)
+	if err != nil {
+		response.Error(c, 400, "Invalid search filter", err.Error())
+		return
+	}
+
+	page, err := transactions.SearchTransactions(filter)
+	if errors.Is(err, transactions.ErrInvalidCursor) {
+		response.Error(c, 400, "Invalid search filter", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to search transactions", err.Error())
+		return
+	}
+	response.Success(c, "Transactions fetched", page)
+}
+
+// POST /admin/transactions/:id/reversals
+func CreateReversalHandler(c *gin.Context) {
+	var req transactions.ReversalInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+	req.TransactionID = c.Param("id")
+
+	rev, err := transactions.RegisterReversal(req, transactions.ReversalSourceAdmin, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Transaction not found", nil)
+		return
+	}
+	if errors.Is(err, transactions.ErrDuplicateReversal) {
+		response.Error(c, 409, "Failed to register reversal", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 400, "Failed to register reversal", err.Error())
+		return
+	}
+	response.Success(c, "Reversal registered", rev)
+}
+
+// GET /admin/transactions/:id/reversals
+func GetReversalsHandler(c *gin.Context) {
+	data, err := transactions.FetchReversals(c.Param("id"))
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch reversals", err.Error())
+		return
+	}
+	response.Success(c, "Reversals fetched", data)
+}
+
+// POST /admin/chargebacks/import
+func ImportChargebacksHandler(c *gin.Context) {
+	var req struct {
+		Records []transactions.ReversalInput `json:"records" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	// Processor files are chargeback feeds unless a record says otherwise.
+	for i := range req.Records {
+		if req.Records[i].Type == "" {
+			req.Records[i].Type = transactions.ReversalChargeback
+		}
+	}
+
+	results := transactions.ImportReversals(req.Records, c.GetString("user_id"))
+	response.Success(c, "Chargeback import processed", results)
+}
+
+// GET /admin/merchants?risk_status=&limit=50&offset=0
+func GetMerchantsHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := merchants.FetchMerchants(c.Query("risk_status"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch merchants", err.Error())
+		return
+	}
+	response.Success(c, "Merchants fetched", data)
+}
+
+// GET /admin/merchants/stats?limit=20
+func GetMerchantLeaderboardHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	data, err := GetMerchantLeaderboard(limit)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch merchant stats", err.Error())
+		return
+	}
+	response.Success(c, "Merchant stats fetched", data)
+}
+
+// GET /admin/merchants/:id
+func GetMerchantHandler(c *gin.Context) {
+	data, err := GetMerchantOverview(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Merchant not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch merchant", err.Error())
+		return
+	}
+	response.Success(c, "Merchant fetched", data)
+}
+
+// PUT /admin/merchants/:id/risk
+func SetMerchantRiskHandler(c *gin.Context) {
+	var req struct {
+		RiskStatus string `json:"risk_status" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	merchant, err := merchants.SetRiskStatus(c.Param("id"), req.RiskStatus, req.Reason, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Merchant not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 400, "Failed to update merchant risk", err.Error())
+		return
+	}
+	response.Success(c, "Merchant risk updated", merchant)
+}
+
+// GET /admin/risk-lists?list=DENY&type=IP&include_expired=false&limit=50&offset=0
+func GetRiskListEntriesHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	includeExpired := c.Query("include_expired") == "true"
+
+	data, err := risklists.FetchEntries(c.Query("list"), c.Query("type"), includeExpired, limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch risk list entries", err.Error())
+		return
+	}
+	response.Success(c, "Risk list entries fetched", data)
+}
+
+// GET /admin/risk-lists/:id
+func GetRiskListEntryHandler(c *gin.Context) {
+	entry, err := risklists.FetchEntry(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Risk list entry not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch risk list entry", err.Error())
+		return
+	}
+	response.Success(c, "Risk list entry fetched", entry)
+}
+
+// POST /admin/risk-lists
+func CreateRiskListEntryHandler(c *gin.Context) {
+	var req risklists.EntryInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	entry, err := risklists.AddEntry(req, c.GetString("user_id"))
+	if errors.Is(err, risklists.ErrEntryExists) {
+		response.Error(c, 409, "Failed to add risk list entry", err.Error())
+		return
+	}
+	if err != nil {
+		riskListError(c, "Failed to add risk list entry", err)
+		return
+	}
+	response.Success(c, "Risk list entry added", entry)
+}
+
+/*
+PUT /admin/risk-lists/:id
+
+Replaces list, reason and expires_at; leaving expires_at out makes the
+entry permanent.
+*/
+func UpdateRiskListEntryHandler(c *gin.Context) {
+	var req risklists.EntryInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	entry, err := risklists.UpdateEntry(c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		riskListError(c, "Failed to update risk list entry", err)
+		return
+	}
+	response.Success(c, "Risk list entry updated", entry)
+}
+
+// DELETE /admin/risk-lists/:id
+func DeleteRiskListEntryHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	if err := risklists.RemoveEntry(c.Param("id"), req.Reason, c.GetString("user_id")); err != nil {
+		riskListError(c, "Failed to remove risk list entry", err)
+		return
+	}
+	response.Success(c, "Risk list entry removed", nil)
+}
+
+func riskListError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "Risk list entry not found", nil)
+	case errors.Is(err, risklists.ErrUnknownList),
+		errors.Is(err, risklists.ErrUnknownType),
+		errors.Is(err, risklists.ErrInvalidValue),
+		errors.Is(err, risklists.ErrInvalidExpiry):
+		response.Error(c, 400, message, err.Error())
+	default:
+		response.Error(c, 500, message, err.Error())
+	}
+}
+
+// GET /admin/dead-letters?status=DEAD&limit=50&offset=0
+func GetDeadLettersHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := events.GetDeadLetters(c.Query("status"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch dead letters", err.Error())
+		return
+	}
+	response.Success(c, "Dead letters fetched", data)
+}
+
+// POST /admin/dead-letters/:id/replay
+func ReplayDeadLetterHandler(c *gin.Context) {
+	actor := c.GetString("user_id")
+
+	letter, err := events.ReplayDeadLetter(c.Param("id"), actor)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Dead letter not found", nil)
+		return
+	}
+	if errors.Is(err, events.ErrDeadLetterReplayed) {
+		response.Error(c, 409, "Failed to replay dead letter", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to replay dead letter", err.Error())
+		return
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "DEAD_LETTER_REPLAYED",
+		EntityType:  "DEAD_LETTER",
+		EntityID:    letter.ID,
+		Description: "Replayed to " + letter.Queue + " by " + actor,
+		CreatedAt:   time.Now(),
+	})
+
+	response.Success(c, "Dead letter replayed", letter)
+}
+
+// POST /admin/webhooks
+func CreateWebhookHandler(c *gin.Context) {
+	var req webhooks.EndpointInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	endpoint, secret, err := webhooks.RegisterEndpoint(req, c.GetString("user_id"))
+	if errors.Is(err, webhooks.ErrInvalidURL) || errors.Is(err, webhooks.ErrInvalidFilters) || errors.Is(err, webhooks.ErrInvalidMerchant) {
+		response.Error(c, 400, "Invalid webhook endpoint", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to create webhook endpoint", err.Error())
+		return
+	}
+
+	// The secret is shown once; it is never returned again.
+	response.Success(c, "Webhook endpoint created", gin.H{
+		"endpoint": endpoint,
+		"secret":   secret,
+	})
+}
+
+// GET /admin/webhooks
+func GetWebhooksHandler(c *gin.Context) {
+	data, err := webhooks.FetchEndpoints()
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch webhook endpoints", err.Error())
+		return
+	}
+	response.Success(c, "Webhook endpoints fetched", data)
+}
+
+// PATCH /admin/webhooks/:id
+func UpdateWebhookHandler(c *gin.Context) {
+	var req webhooks.EndpointInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	endpoint, err := webhooks.ChangeEndpoint(c.Param("id"), req, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Webhook endpoint not found", nil)
+		return
+	}
+	if errors.Is(err, webhooks.ErrInvalidURL) || errors.Is(err, webhooks.ErrInvalidFilters) || errors.Is(err, webhooks.ErrInvalidMerchant) {
+		response.Error(c, 400, "Invalid webhook endpoint", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to update webhook endpoint", err.Error())
+		return
+	}
+	response.Success(c, "Webhook endpoint updated", endpoint)
+}
+
+// DELETE /admin/webhooks/:id
+func DeleteWebhookHandler(c *gin.Context) {
+	err := webhooks.RemoveEndpoint(c.Param("id"), c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Webhook endpoint not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to delete webhook endpoint", err.Error())
+		return
+	}
+	response.Success(c, "Webhook endpoint deleted", nil)
+}
+
+// GET /admin/webhooks/:id/deliveries?status=FAILED&limit=50&offset=0
+func GetWebhookDeliveriesHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := webhooks.FetchDeliveries(c.Param("id"), c.Query("status"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch webhook deliveries", err.Error())
+		return
+	}
+	response.Success(c, "Webhook deliveries fetched", data)
+}
+
+// GET /admin/webhook-deliveries/:id
+func GetWebhookDeliveryHandler(c *gin.Context) {
+	data, err := webhooks.FetchDeliveryDetail(c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Webhook delivery not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch webhook delivery", err.Error())
+		return
+	}
+	response.Success(c, "Webhook delivery fetched", data)
+}
+
+// POST /admin/webhook-deliveries/:id/redeliver
+func RedeliverWebhookHandler(c *gin.Context) {
+	delivery, err := webhooks.Redeliver(c.Param("id"), c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Webhook delivery not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to redeliver webhook", err.Error())
+		return
+	}
+	response.Success(c, "Webhook redelivery queued", delivery)
+}
+
+// POST /admin/users/:id/force-logout
+func ForceLogoutHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	revoked, err := auth.ForceLogout(c.Param("id"), req.Reason, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "User not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to force logout", err.Error())
+		return
+	}
+	response.Success(c, "User logged out everywhere", gin.H{
+		"sessions_revoked": revoked,
+	})
+}
+
+// GET /admin/users/:id/login-attempts?limit=50&offset=0
+func GetUserLoginAttemptsHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := auth.GetUserLoginAttempts(c.Param("id"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch login attempts", err.Error())
+		return
+	}
+	response.Success(c, "Login attempts fetched", data)
+}
+
+// POST /admin/users/:id/unlock
+func UnlockUserHandler(c *gin.Context) {
+	err := auth.UnlockAccount(c.Param("id"), c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "User not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to unlock user", err.Error())
+		return
+	}
+	response.Success(c, "User unlocked", nil)
+}
+
+// GET /admin/users?q=&status=&role=&limit=50&offset=0
+func GetUsersHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := users.ListUsers(users.UserFilter{
+		Query:  c.Query("q"),
+		Status: c.Query("status"),
+		Role:   c.Query("role"),
+		Limit:  limit,
+		Offset: offset,
+	})
+	if errors.Is(err, users.ErrInvalidStatusQuery) {
+		response.Error(c, 400, "Invalid filter", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch users", err.Error())
+		return
+	}
+	response.Success(c, "Users fetched", data)
+}
+
+// GET /admin/users/:id
+func GetUserHandler(c *gin.Context) {
+	profile, err := users.GetProfile(c.Param("id"))
+	if errors.Is(err, users.ErrUserNotFound) {
+		response.Error(c, 404, "User not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch user", err.Error())
+		return
+	}
+	response.Success(c, "User fetched", profile)
+}
+
+// POST /admin/users/:id/suspend
+func SuspendUserHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	profile, err := users.SuspendUser(c.Param("id"), req.Reason, c.GetString("user_id"))
+	if userStatusError(c, "Failed to suspend user", err) {
+		return
+	}
+	response.Success(c, "User suspended", profile)
+}
+
+// POST /admin/users/:id/restrict
+func RestrictUserHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	profile, err := users.RestrictUser(c.Param("id"), req.Reason, c.GetString("user_id"))
+	if userStatusError(c, "Failed to restrict user", err) {
+		return
+	}
+	response.Success(c, "User restricted", profile)
+}
+
+// POST /admin/users/:id/reactivate lifts a restriction or a suspension.
+func ReactivateUserHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	profile, err := users.ReactivateUser(c.Param("id"), req.Reason, c.GetString("user_id"))
+	if userStatusError(c, "Failed to reactivate user", err) {
+		return
+	}
+	response.Success(c, "User reactivated", profile)
+}
+
+// GET /admin/users/:id/status-history
+func GetUserStatusHistoryHandler(c *gin.Context) {
+	data, err := users.GetStatusHistory(c.Param("id"))
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch status history", err.Error())
+		return
+	}
+	response.Success(c, "Status history fetched", data)
+}
+
+// userStatusError writes the response for a failed status change and reports whether there was one.
+func userStatusError(c *gin.Context, message string, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, users.ErrUserNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		response.Error(c, 404, "User not found", nil)
+	case errors.Is(err, users.ErrOwnStatusChange):
+		response.Error(c, 400, message, err.Error())
+	case errors.Is(err, auth.ErrStatusUnchanged),
+		errors.Is(err, users.ErrAccountDeleted),
+		errors.Is(err, users.ErrLastAdminAccount):
+		response.Error(c, 409, message, err.Error())
+	default:
+		response.Error(c, 500, message, err.Error())
+	}
+	return true
+}
+
+// GET /admin/roles
+func GetRolesHandler(c *gin.Context) {
+	response.Success(c, "Roles fetched", auth.RolePermissions)
+}
+
+// PUT /admin/users/:id/role
+func AssignRoleHandler(c *gin.Context) {
+	var req struct {
+		Role   string `json:"role" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	user, err := auth.AssignRole(c.Param("id"), req.Role, req.Reason, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "User not found", nil)
+		return
+	}
+	if errors.Is(err, auth.ErrUnknownRole) || errors.Is(err, auth.ErrOwnRoleChange) {
+		response.Error(c, 400, "Failed to assign role", err.Error())
+		return
+	}
+	if errors.Is(err, auth.ErrLastAdmin) || errors.Is(err, auth.ErrRoleUnchanged) {
+		response.Error(c, 409, "Failed to assign role", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to assign role", err.Error())
+		return
+	}
+	response.Success(c, "Role assigned", gin.H{
+		"user_id": user.ID,
+		"role":    user.Role,
+	})
+}
+
+// POST /admin/service-accounts
+func CreateServiceAccountHandler(c *gin.Context) {
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		MerchantID  string `json:"merchant_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	account, err := auth.CreateServiceAccount(req.Name, req.Description, req.MerchantID, c.GetString("user_id"))
+	if errors.Is(err, auth.ErrInvalidName) || errors.Is(err, auth.ErrInvalidMerchant) {
+		response.Error(c, 400, "Failed to create service account", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to create service account", err.Error())
+		return
+	}
+	response.Success(c, "Service account created", account)
+}
+
+// GET /admin/service-accounts
+func GetServiceAccountsHandler(c *gin.Context) {
+	data, err := auth.FetchServiceAccounts()
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch service accounts", err.Error())
+		return
+	}
+	response.Success(c, "Service accounts fetched", data)
+}
+
+// POST /admin/service-accounts/:id/keys
+func IssueAPIKeyHandler(c *gin.Context) {
+	var req auth.APIKeyInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	key, raw, err := auth.IssueAPIKey(c.Param("id"), req, c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Service account not found", nil)
+		return
+	}
+	if errors.Is(err, auth.ErrInvalidScopes) {
+		response.Error(c, 400, "Failed to issue API key", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to issue API key", err.Error())
+		return
+	}
+
+	// The key is shown once; only its prefix is kept in readable form.
+	response.Success(c, "API key issued", gin.H{
+		"key":     key,
+		"api_key": raw,
+	})
+}
+
+// GET /admin/service-accounts/:id/keys
+func GetAPIKeysHandler(c *gin.Context) {
+	data, err := auth.FetchAPIKeys(c.Param("id"))
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch API keys", err.Error())
+		return
+	}
+	response.Success(c, "API keys fetched", data)
+}
+
+// DELETE /admin/api-keys/:id
+func RevokeAPIKeyHandler(c *gin.Context) {
+	err := auth.RevokeAPIKey(c.Param("id"), c.GetString("user_id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "API key not found", nil)
+		return
+	}
+	if errors.Is(err, auth.ErrAPIKeyRevoked) {
+		response.Error(c, 409, "Failed to revoke API key", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to revoke API key", err.Error())
+		return
+	}
+	response.Success(c, "API key revoked", nil)
+}
+
+// GET /admin/service-accounts/:id/requests?limit=50&offset=0
+func GetServiceAccountRequestsHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	data, err := auth.FetchAPIKeyRequests(c.Param("id"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch API key requests", err.Error())
+		return
+	}
+	response.Success(c, "API key requests fetched", data)
+}
@@ -2,6 +2,9 @@ package admin
 
 import (
 	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/merchants"
+
+	"gorm.io/gorm"
 )
 
 type TransactionSummary struct {
@@ -72,3 +75,60 @@ func GetAuditLogs(limit int) ([]AuditLogEntry, error) {
 
 	return logs, err
 }
+
+// -------- Merchants --------
+
+type MerchantStats struct {
+	MerchantID      string  `json:"merchant_id"`
+	TxnCount        int64   `json:"txn_count"`
+	TotalAmount     float64 `json:"total_amount"`
+	UniqueUsers     int64   `json:"unique_users"`
+	FlaggedCount    int64   `json:"flagged_count"`
+	BlockedCount    int64   `json:"blocked_count"`
+	ChargebackCount int64   `json:"chargeback_count"`
+}
+
+type MerchantOverview struct {
+	Merchant *merchants.Merchant `json:"merchant"`
+	Stats    MerchantStats       `json:"stats"`
+}
+
+func merchantStatsQuery() *gorm.DB {
+	return database.DB.
+		Table("transactions").
+		Select(`merchant_id,
+			COUNT(*) AS txn_count,
+			COALESCE(SUM(amount), 0) AS total_amount,
+			COUNT(DISTINCT user_id) AS unique_users,
+			COUNT(*) FILTER (WHERE status = 'FLAGGED') AS flagged_count,
+			COUNT(*) FILTER (WHERE status = 'BLOCKED') AS blocked_count,
+			COUNT(*) FILTER (WHERE status = 'CHARGEBACK') AS chargeback_count`).
+		Group("merchant_id")
+}
+
+func GetMerchantOverview(merchantID string) (*MerchantOverview, error) {
+	merchant, err := merchants.FetchMerchant(merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := MerchantStats{MerchantID: merchantID}
+	err = merchantStatsQuery().
+		Where("merchant_id = ?", merchantID).
+		Scan(&stats).Error
+
+	return &MerchantOverview{Merchant: merchant, Stats: stats}, err
+}
+
+// GetMerchantLeaderboard ranks merchants by how much fraud they attract.
+func GetMerchantLeaderboard(limit int) ([]MerchantStats, error) {
+	var stats []MerchantStats
+
+	err := merchantStatsQuery().
+		Where("merchant_id <> ''").
+		Order("chargeback_count DESC, blocked_count DESC, flagged_count DESC").
+		Limit(limit).
+		Scan(&stats).Error
+
+	return stats, err
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/mailer"
+	"fraud-detection-backend/internal/notifications"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	UserActive     = "ACTIVE"
+	UserRestricted = "RESTRICTED"
+	UserSuspended  = "SUSPENDED"
+	UserDeleted    = "DELETED"
+
+	RevokedByPasswordChange = "PASSWORD_CHANGED"
+	RevokedBySuspension     = "SUSPENDED"
+)
+
+var (
+	ErrAccountSuspended = errors.New("account is suspended")
+	ErrWrongPassword    = errors.New("current password is incorrect")
+	ErrEmailUnchanged   = errors.New("email is unchanged")
+)
+
+/*
+CheckPassword loads the user and confirms the password, for changes
+that must not go through on a stolen session alone.
+*/
+func CheckPassword(userID, password string) (*User, error) {
+	user, err := FindUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, ErrWrongPassword
+	}
+	return user, nil
+}
+
+/*
+ChangePassword sets a new password for a signed-in user. Every other
+session is ended; the one making the change stays signed in.
+*/
+func ChangePassword(userID, sessionID, current, password string) error {
+	user, err := CheckPassword(userID, current)
+	if err != nil {
+		return err
+	}
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Model(&User{}).Where("id = ?", user.ID).Update("password", hash).Error; err != nil {
+		return err
+	}
+
+	if err := endOtherSessions(user.ID, sessionID, RevokedByPasswordChange); err != nil {
+		log.Println("❌ Failed to end sessions after password change:", user.ID, err)
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "PASSWORD_CHANGED",
+		EntityType:  "USER",
+		EntityID:    user.ID,
+		Description: "Password changed by the user",
+		CreatedAt:   time.Now(),
+	})
+	notifications.CreateAccountNotification(
+		user.ID,
+		"PASSWORD_CHANGED",
+		"Password changed",
+		"Your password was changed and your other devices were signed out. If this was not you, reset your password.",
+	)
+
+	return nil
+}
+
+// endOtherSessions revokes all of the user's sessions except keepSessionID.
+func endOtherSessions(userID, keepSessionID, reason string) error {
+	var ids []string
+	now := time.Now()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&Session{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"revoked_at":     now,
+				"revoked_reason": reason,
+			}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		cacheSessionRevocation(id, now)
+	}
+	return nil
+}
+
+/*
+ChangeEmail asks to move the account to a new address. The address is
+kept as pending and gets a verification link; the account keeps its
+current address until VerifyEmail swaps the new one in, so a typo or
+someone else's inbox cannot take the account over. The current address
+is told about the request so a hijacker cannot do it quietly.
+*/
+func ChangeEmail(userID, password, email string) (*User, error) {
+	user, err := CheckPassword(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	email, err = NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if email == user.Email {
+		return nil, ErrEmailUnchanged
+	}
+
+	var existing int64
+	if err := database.DB.Model(&User{}).Where("LOWER(email) = ?", email).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrEmailTaken
+	}
+
+	if err := database.DB.Model(&User{}).Where("id = ?", user.ID).
+		Update("pending_email", email).Error; err != nil {
+		return nil, err
+	}
+	user.PendingEmail = email
+
+	if err := sendVerificationLink(user, email); err != nil {
+		log.Println("❌ Failed to send verification email:", user.ID, err)
+	}
+	if err := mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Email address change requested",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"Someone asked to change the email address on your account to " + email + ".\n" +
+			"It changes once the link sent there is opened. If this was not you, contact support right away.",
+	}); err != nil {
+		log.Println("❌ Failed to notify current email address:", user.ID, err)
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "EMAIL_CHANGE_REQUESTED",
+		EntityType:  "USER",
+		EntityID:    user.ID,
+		Description: user.Email + " -> " + email,
+		CreatedAt:   time.Now(),
+	})
+
+	return user, nil
+}
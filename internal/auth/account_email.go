@@ -0,0 +1,348 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/mailer"
+	"fraud-detection-backend/internal/notifications"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	RevokedByPasswordReset = "PASSWORD_RESET"
+)
+
+var (
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+/*
+issueUserToken creates a token for a link mailed to the address to. It
+replaces any unused token of the same purpose, so only the most recent
+link works.
+*/
+func issueUserToken(user *User, purpose, to string, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+	now := time.Now()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, purpose).
+			Delete(&UserToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&UserToken{
+			ID:        uuid.NewString(),
+			UserID:    user.ID,
+			Purpose:   purpose,
+			Email:     to,
+			TokenHash: hashToken(raw),
+			ExpiresAt: now.Add(ttl),
+			CreatedAt: now,
+		}).Error
+	})
+
+	return raw, err
+}
+
+// consumeUserToken spends a token inside tx, so it is only used up if the rest succeeds.
+func consumeUserToken(tx *gorm.DB, raw, purpose string) (*UserToken, error) {
+	var token UserToken
+	err := tx.First(&token, "token_hash = ? AND purpose = ?", hashToken(raw), purpose).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	res := tx.Model(&UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrInvalidUserToken
+	}
+
+	return &token, nil
+}
+
+func appLink(path, token string) string {
+	return config.AppConfig.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+func SendVerificationEmail(user *User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return sendVerificationLink(user, user.Email)
+}
+
+// sendVerificationLink mails a verification link to the account's address or its pending one.
+func sendVerificationLink(user *User, to string) error {
+	token, err := issueUserToken(user, TokenVerifyEmail, to, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      to,
+		Subject: "Confirm your email address",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"Confirm your email address by opening this link:\n" +
+			appLink("/verify-email", token) + "\n\n" +
+			"The link expires in 48 hours.",
+	})
+}
+
+/*
+ResendVerificationEmail sends a fresh link to the signed-in user, to the
+pending address if they asked to change it.
+*/
+func ResendVerificationEmail(userID string) error {
+	user, err := FindUser(userID)
+	if err != nil {
+		return err
+	}
+	if user.PendingEmail != "" {
+		return sendVerificationLink(user, user.PendingEmail)
+	}
+	return SendVerificationEmail(user)
+}
+
+/*
+VerifyEmail confirms the address a link was sent to. A link for the
+pending address is what actually changes the account's email: the new
+address is swapped in and reset links mailed to the old one are voided.
+*/
+func VerifyEmail(raw string) (*User, error) {
+	var userID, oldEmail, newEmail string
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, raw, TokenVerifyEmail)
+		if err != nil {
+			return err
+		}
+
+		var user User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&user, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+		userID = user.ID
+		now := time.Now()
+
+		switch {
+		case token.Email == user.Email:
+			return tx.Model(&User{}).Where("id = ?", user.ID).Update("email_verified_at", now).Error
+
+		case user.PendingEmail != "" && token.Email == user.PendingEmail:
+			err := tx.Model(&User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+				"email":             user.PendingEmail,
+				"pending_email":     "",
+				"email_verified_at": now,
+			}).Error
+			// Someone else registered or confirmed the address meanwhile.
+			if database.IsUniqueViolation(err) {
+				return ErrEmailTaken
+			}
+			if err != nil {
+				return err
+			}
+			oldEmail, newEmail = user.Email, user.PendingEmail
+
+			// Reset links already in the old inbox must not outlive the change.
+			return tx.Where("user_id = ? AND purpose = ? AND used_at IS NULL", user.ID, TokenResetPassword).
+				Delete(&UserToken{}).Error
+
+		default:
+			// Sent to an address the account has since moved away from.
+			return ErrInvalidUserToken
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if newEmail != "" {
+		audit.CreateLog(&audit.AuditLog{
+			ID:          uuid.NewString(),
+			EventType:   "EMAIL_CHANGED",
+			EntityType:  "USER",
+			EntityID:    userID,
+			Description: oldEmail + " -> " + newEmail,
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	return FindUser(userID)
+}
+
+/*
+Reset mails are sent by a few background workers from a bounded queue,
+so a flood of requests cannot start an unbounded number of goroutines.
+When the queue is full the request is dropped; the user can ask again.
+*/
+const (
+	passwordResetWorkers   = 4
+	passwordResetQueueSize = 256
+)
+
+var (
+	passwordResetQueue       = make(chan string, passwordResetQueueSize)
+	startPasswordResetWorker sync.Once
+)
+
+/*
+RequestPasswordReset mails a reset link if the email belongs to a user.
+It is silent either way, so the endpoint cannot be used to find out
+who has an account: the lookup and the mail happen in the background,
+so the response takes as long whether or not there is one. Each address
+gets a few links per hour at most.
+*/
+func RequestPasswordReset(email string) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return
+	}
+	if !allowPasswordReset(email, time.Now()) {
+		log.Println("⚠️ Password reset rate limited:", email)
+		return
+	}
+
+	startPasswordResetWorker.Do(func() {
+		for i := 0; i < passwordResetWorkers; i++ {
+			go func() {
+				for email := range passwordResetQueue {
+					if err := sendPasswordReset(email); err != nil {
+						log.Println("❌ Failed to send password reset:", err)
+					}
+				}
+			}()
+		}
+	})
+
+	select {
+	case passwordResetQueue <- email:
+	default:
+		log.Println("⚠️ Password reset queue full, dropped:", email)
+	}
+}
+
+func sendPasswordReset(email string) error {
+	var user User
+	err := database.DB.Where("LOWER(email) = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := issueUserToken(&user, TokenResetPassword, user.Email, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	return mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"Someone asked to reset the password for your account. If it was you, open this link:\n" +
+			appLink("/reset-password", token) + "\n\n" +
+			"The link expires in 1 hour. If it was not you, you can ignore this email.",
+	})
+}
+
+/*
+ResetPassword sets a new password from a reset link. Every session is
+ended and any lockout lifted, since whoever was locked out or signed
+in with the old password should not carry on.
+*/
+func ResetPassword(raw, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	var user User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, raw, TokenResetPassword)
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+		// A link mailed to an address the account no longer has is void.
+		if user.Email != token.Email {
+			return ErrInvalidUserToken
+		}
+
+		updates := map[string]interface{}{"password": hash}
+		// Receiving the link proves the address works.
+		if user.EmailVerifiedAt == nil {
+			updates["email_verified_at"] = time.Now()
+		}
+		return tx.Model(&User{}).Where("id = ?", user.ID).Updates(updates).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := revokeAllSessions(user.ID, RevokedByPasswordReset, "password reset", user.ID); err != nil {
+		log.Println("❌ Failed to end sessions after password reset:", user.ID, err)
+	}
+	ClearAccountThrottle(user.Email)
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "PASSWORD_RESET",
+		EntityType:  "USER",
+		EntityID:    user.ID,
+		Description: "Password reset via emailed link",
+		CreatedAt:   time.Now(),
+	})
+	notifications.CreateAccountNotification(
+		user.ID,
+		"PASSWORD_CHANGED",
+		"Password changed",
+		"Your password was reset and you were signed out everywhere. If this was not you, contact support.",
+	)
+
+	return nil
+}
+
+// DeleteExpiredUserTokens drops link tokens that can no longer be used.
+func DeleteExpiredUserTokens(before time.Time) (int64, error) {
+	res := database.DB.Where("expires_at < ?", before).Delete(&UserToken{})
+	return res.RowsAffected, res.Error
+}
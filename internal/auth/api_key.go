@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/merchants"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// APIKeyPrefix marks a bearer credential as an API key rather than a JWT.
+	APIKeyPrefix = "fdk_"
+
+	ScopeTransactionsWrite = "transactions:write"
+
+	apiKeyPrefixHexLen     = 12
+	defaultAPIKeyRateLimit = 600
+	// LastUsedAt is only written this often, not on every request.
+	lastUsedResolution = time.Minute
+)
+
+var Scopes = []string{ScopeTransactionsWrite}
+
+var (
+	ErrInvalidAPIKey   = errors.New("invalid API key")
+	ErrInvalidScopes   = errors.New("scopes must be a non-empty list of known scopes")
+	ErrAPIKeyRevoked   = errors.New("API key is already revoked")
+	ErrInvalidMerchant = errors.New("merchant_id must name an existing merchant")
+)
+
+type APIKeyInput struct {
+	Scopes             []string   `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+}
+
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Split(k.Scopes, ",") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func validateScopes(scopes []string) (string, error) {
+	if len(scopes) == 0 {
+		return "", ErrInvalidScopes
+	}
+	for _, s := range scopes {
+		known := false
+		for _, k := range Scopes {
+			known = known || s == k
+		}
+		if !known {
+			return "", ErrInvalidScopes
+		}
+	}
+	return strings.Join(scopes, ","), nil
+}
+
+func CreateServiceAccount(name, description, merchantID, actor string) (*ServiceAccount, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	if merchantID == "" || merchantID == merchants.UnknownMerchantID {
+		return nil, ErrInvalidMerchant
+	}
+	if _, err := merchants.FindByID(merchantID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidMerchant
+	} else if err != nil {
+		return nil, err
+	}
+
+	account := &ServiceAccount{
+		ID:          uuid.NewString(),
+		Name:        name,
+		MerchantID:  merchantID,
+		Description: description,
+		Active:      true,
+		CreatedBy:   actor,
+		CreatedAt:   time.Now(),
+	}
+	if err := database.DB.Create(account).Error; err != nil {
+		return nil, err
+	}
+
+	logAPIKeyChange("SERVICE_ACCOUNT_CREATED", "SERVICE_ACCOUNT", account.ID, "By "+actor+": "+name+" for merchant "+merchantID)
+	return account, nil
+}
+
+func FetchServiceAccounts() ([]ServiceAccount, error) {
+	var accounts []ServiceAccount
+	err := database.DB.Order("created_at DESC").Find(&accounts).Error
+	return accounts, err
+}
+
+func FetchAPIKeys(serviceAccountID string) ([]APIKey, error) {
+	var keys []APIKey
+	err := database.DB.
+		Where("service_account_id = ?", serviceAccountID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+// IssueAPIKey creates a key for the account and returns it; the raw key is not retrievable later.
+func IssueAPIKey(serviceAccountID string, in APIKeyInput, actor string) (*APIKey, string, error) {
+	var account ServiceAccount
+	if err := database.DB.First(&account, "id = ?", serviceAccountID).Error; err != nil {
+		return nil, "", err
+	}
+
+	scopes, err := validateScopes(in.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	limit := in.RateLimitPerMinute
+	if limit <= 0 {
+		limit = defaultAPIKeyRateLimit
+	}
+
+	prefixBytes := make([]byte, apiKeyPrefixHexLen/2)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	prefix := APIKeyPrefix + hex.EncodeToString(prefixBytes)
+	raw := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &APIKey{
+		ID:                 uuid.NewString(),
+		ServiceAccountID:   account.ID,
+		Prefix:             prefix,
+		KeyHash:            hashToken(raw),
+		Scopes:             scopes,
+		RateLimitPerMinute: limit,
+		ExpiresAt:          in.ExpiresAt,
+		CreatedBy:          actor,
+		CreatedAt:          time.Now(),
+	}
+	if err := database.DB.Create(key).Error; err != nil {
+		return nil, "", err
+	}
+
+	logAPIKeyChange("API_KEY_ISSUED", "API_KEY", key.ID, "By "+actor+" for "+account.Name+", scopes: "+scopes)
+	return key, raw, nil
+}
+
+func RevokeAPIKey(id, actor string) error {
+	res := database.DB.Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		var key APIKey
+		if err := database.DB.First(&key, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return ErrAPIKeyRevoked
+	}
+
+	logAPIKeyChange("API_KEY_REVOKED", "API_KEY", id, "By "+actor)
+	return nil
+}
+
+/*
+AuthenticateAPIKey resolves a raw key to its key and service account.
+Every failure looks the same to the caller.
+*/
+func AuthenticateAPIKey(raw string) (*APIKey, *ServiceAccount, error) {
+	// The secret part is base64url and may itself contain "_".
+	i := len(APIKeyPrefix) + apiKeyPrefixHexLen
+	if !strings.HasPrefix(raw, APIKeyPrefix) || len(raw) <= i || raw[i] != '_' {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var key APIKey
+	err := database.DB.First(&key, "prefix = ?", raw[:i]).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(raw)), []byte(key.KeyHash)) != 1 ||
+		key.RevokedAt != nil ||
+		(key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var account ServiceAccount
+	if err := database.DB.First(&account, "id = ?", key.ServiceAccountID).Error; err != nil {
+		return nil, nil, err
+	}
+	if !account.Active {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedResolution {
+		database.DB.Model(&APIKey{}).Where("id = ?", key.ID).Update("last_used_at", now)
+	}
+
+	return &key, &account, nil
+}
+
+func RecordAPIKeyRequest(r *APIKeyRequest) error {
+	return database.DB.Create(r).Error
+}
+
+func FetchAPIKeyRequests(serviceAccountID string, limit, offset int) ([]APIKeyRequest, error) {
+	var requests []APIKeyRequest
+	err := database.DB.
+		Where("service_account_id = ?", serviceAccountID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&requests).Error
+	return requests, err
+}
+
+func logAPIKeyChange(eventType, entityType, id, description string) {
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   eventType,
+		EntityType:  entityType,
+		EntityID:    id,
+		Description: description,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// DeleteAPIKeyRequestsBefore trims the request trail to its retention window.
+func DeleteAPIKeyRequestsBefore(before time.Time) (int64, error) {
+	res := database.DB.Where("created_at < ?", before).Delete(&APIKeyRequest{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,50 @@
+package auth
+
+import "time"
+
+/*
+ServiceAccount is a non-human client, such as a merchant backend. It
+authenticates with API keys instead of a password and never gets a
+session. It may only submit transactions for MerchantID; accounts
+created before merchants were linked have none and are refused.
+*/
+type ServiceAccount struct {
+	ID          string    `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"`
+	MerchantID  string    `gorm:"index;not null;default:''" json:"merchant_id"`
+	Description string    `json:"description"`
+	Active      bool      `gorm:"default:true" json:"active"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+/*
+APIKey is shown once at creation. We keep its public prefix, used to
+look it up and to recognise it in logs, and a SHA-256 of the whole key.
+*/
+type APIKey struct {
+	ID                 string     `gorm:"type:uuid;primaryKey" json:"id"`
+	ServiceAccountID   string     `gorm:"type:uuid;index;not null" json:"service_account_id"`
+	Prefix             string     `gorm:"uniqueIndex;not null" json:"prefix"`
+	KeyHash            string     `gorm:"not null" json:"-"`
+	Scopes             string     `json:"scopes"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt         *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy          string     `json:"created_by"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// APIKeyRequest is the audit trail of every request made with an API key.
+type APIKeyRequest struct {
+	ID               string    `gorm:"type:uuid;primaryKey" json:"id"`
+	APIKeyID         string    `gorm:"type:uuid;index" json:"api_key_id"`
+	ServiceAccountID string    `gorm:"type:uuid;index" json:"service_account_id"`
+	Method           string    `json:"method"`
+	Path             string    `json:"path"`
+	Status           int       `json:"status"`
+	IP               string    `json:"ip"`
+	RequestID        string    `json:"request_id"`
+	CreatedAt        time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+const (
+	accessCookie  = "access_token"
+	refreshCookie = "refresh_token"
+
+	// The refresh cookie is only sent to the endpoints that need it.
+	refreshCookiePath = "/auth"
+)
+
+// setAuthCookies sets both cookies to expire with the tokens they hold.
+func setAuthCookies(c *gin.Context, accessToken, refreshToken string) {
+	setAccessCookie(c, accessToken)
+	c.SetCookie(
+		refreshCookie,
+		refreshToken,
+		int(RefreshTokenTTL().Seconds()),
+		refreshCookiePath,
+		"",
+		false,
+		true,
+	)
+}
+
+func setAccessCookie(c *gin.Context, accessToken string) {
+	c.SetCookie(
+		accessCookie,
+		accessToken,
+		int(AccessTokenTTL().Seconds()),
+		"/",
+		"",    // domain (empty = current)
+		false, // secure (true in prod with HTTPS)
+		true,  // httpOnly
+	)
+}
+
+func ClearAuthCookies(c *gin.Context) {
+	c.SetCookie(accessCookie, "", -1, "/", "", false, true)
+	c.SetCookie(refreshCookie, "", -1, refreshCookiePath, "", false, true)
+}
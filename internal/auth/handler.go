@@ -1,6 +1,11 @@
 package auth
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
 	"fraud-detection-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -19,10 +24,19 @@ func RegisterHandler(c *gin.Context) {
 	}
 
 	user, err := Register(req.Name, req.Email, req.Password)
-	if err != nil {
+	var policy *PasswordPolicyError
+	if errors.As(err, &policy) || errors.Is(err, ErrInvalidEmail) || errors.Is(err, ErrInvalidName) {
 		response.Error(c, 400, "Registration failed", err.Error())
 		return
 	}
+	if errors.Is(err, ErrEmailTaken) {
+		response.Error(c, 409, "Registration failed", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Registration failed", err.Error())
+		return
+	}
 
 	response.Success(c, "User registered", gin.H{
 		"user_id": user.ID,
@@ -33,6 +47,10 @@ func LoginHandler(c *gin.Context) {
 	var req struct {
 		Email    string
 		Password string
+
+		// Optional, sent by clients that know where they are.
+		Latitude  *float64
+		Longitude *float64
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,45 +58,294 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	user, err := Login(req.Email, req.Password)
+	result, err := Login(req.Email, req.Password, LoginContext{
+		DeviceID:  c.GetString("device_id"),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	})
+	var throttled *ThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		response.Error(c, 429, "Login failed", err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		response.Error(c, 403, "Login failed", err.Error())
+		return
+	}
 	if err != nil {
 		response.Error(c, 401, "Login failed", err.Error())
 		return
 	}
 
-	token, err := GenerateToken(user.ID, user.Role)
+	if result.StepUp != nil {
+		response.Success(c, "Additional verification required", gin.H{
+			"step_up_required": true,
+			"method":           result.StepUp.Method,
+			"challenge_id":     result.StepUp.ID,
+			"expires_at":       result.StepUp.ExpiresAt,
+		})
+		return
+	}
+
+	issueSession(c, result.User, false, "Login successful")
+}
+
+// POST /auth/login/step-up
+func StepUpHandler(c *gin.Context) {
+	var req struct {
+		ChallengeID string `json:"challenge_id" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	user, mfa, err := VerifyStepUp(req.ChallengeID, req.Code, c.GetString("device_id"), c.ClientIP())
+	var throttled *ThrottledError
+	if errors.As(err, &throttled) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		response.Error(c, 429, "Verification failed", err.Error())
+		return
+	}
+	if errors.Is(err, ErrMFALocked) {
+		response.Error(c, 429, "Verification failed", err.Error())
+		return
+	}
+	if errors.Is(err, ErrInvalidStepUp) {
+		response.Error(c, 401, "Verification failed", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Verification failed", err.Error())
+		return
+	}
+
+	issueSession(c, user, mfa, "Login successful")
+}
+
+// issueSession starts a session for user and sets the auth cookies.
+func issueSession(c *gin.Context, user *User, mfa bool, message string) {
+	session, refreshToken, err := StartSession(user.ID, c.GetString("device_id"), c.Request.UserAgent(), c.ClientIP(), mfa)
+	if err != nil {
+		response.Error(c, 500, "Session creation failed", err.Error())
+		return
+	}
+
+	token, err := GenerateToken(user.ID, user.Role, session.ID, mfa)
 	if err != nil {
 		response.Error(c, 500, "Token generation failed", err.Error())
 		return
 	}
 
-	// 🔐 Set JWT as HTTP-only cookie
-	c.SetCookie(
-		"access_token", // name
-		token,          // value
-		86400,          // maxAge (1 day)
-		"/",            // path
-		"",             // domain (empty = current)
-		false,          // secure (true in prod with HTTPS)
-		true,           // httpOnly
-	)
+	setAuthCookies(c, token, refreshToken)
 
-	response.Success(c, "Login successful", gin.H{
-		"user_id": user.ID,
-		"role":    user.Role,
+	response.Success(c, message, gin.H{
+		"user_id":        user.ID,
+		"role":           user.Role,
+		"session_id":     session.ID,
+		"email_verified": user.EmailVerifiedAt != nil,
 	})
 }
 
+// GET /auth/login-attempts
+func GetLoginAttemptsHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	attempts, err := GetUserLoginAttempts(c.GetString("user_id"), limit, offset)
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch login attempts", err.Error())
+		return
+	}
+	response.Success(c, "Login attempts fetched", attempts)
+}
+
 func LogoutHandler(c *gin.Context) {
-	c.SetCookie(
-		"access_token",
-		"",
-		-1, // delete cookie
-		"/",
-		"",
-		false,
-		true,
-	)
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		if err := EndSession(c.GetString("user_id"), sessionID, RevokedByLogout); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			response.Error(c, 500, "Logout failed", err.Error())
+			return
+		}
+	}
+
+	expiresAt, _ := c.Get("token_expires_at")
+	if exp, ok := expiresAt.(time.Time); ok {
+		if err := RevokeToken(c.GetString("jti"), c.GetString("user_id"), RevokedByLogout, exp); err != nil {
+			response.Error(c, 500, "Logout failed", err.Error())
+			return
+		}
+	}
+
+	ClearAuthCookies(c)
 
 	response.Success(c, "Logged out successfully", nil)
 }
+
+// POST /auth/refresh
+func RefreshHandler(c *gin.Context) {
+	raw, err := c.Cookie(refreshCookie)
+	if err != nil || raw == "" {
+		response.Error(c, 401, "Unauthorized", "Missing refresh cookie")
+		return
+	}
+
+	session, user, refreshToken, err := Refresh(raw)
+	if errors.Is(err, ErrInvalidRefreshToken) || errors.Is(err, ErrRefreshTokenReused) {
+		ClearAuthCookies(c)
+		response.Error(c, 401, "Unauthorized", err.Error())
+		return
+	}
+	if errors.Is(err, ErrAccountSuspended) {
+		ClearAuthCookies(c)
+		response.Error(c, 403, "Forbidden", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Token refresh failed", err.Error())
+		return
+	}
+
+	token, err := GenerateToken(user.ID, user.Role, session.ID, session.MFAVerified)
+	if err != nil {
+		response.Error(c, 500, "Token generation failed", err.Error())
+		return
+	}
+
+	setAuthCookies(c, token, refreshToken)
+
+	response.Success(c, "Token refreshed", gin.H{
+		"user_id":    user.ID,
+		"role":       user.Role,
+		"session_id": session.ID,
+	})
+}
+
+// GET /auth/sessions
+func GetSessionsHandler(c *gin.Context) {
+	sessions, err := FetchSessions(c.GetString("user_id"))
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch sessions", err.Error())
+		return
+	}
+
+	current := c.GetString("session_id")
+	data := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		data = append(data, gin.H{
+			"session":    s,
+			"is_current": s.ID == current,
+		})
+	}
+
+	response.Success(c, "Sessions fetched", data)
+}
+
+// DELETE /auth/sessions/:id
+func RevokeSessionHandler(c *gin.Context) {
+	err := EndSession(c.GetString("user_id"), c.Param("id"), RevokedByUser)
+	if errors.Is(err, ErrSessionNotFound) {
+		response.Error(c, 404, "Session not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to revoke session", err.Error())
+		return
+	}
+
+	if c.Param("id") == c.GetString("session_id") {
+		ClearAuthCookies(c)
+	}
+
+	response.Success(c, "Session revoked", nil)
+}
+
+// POST /auth/verify-email
+func VerifyEmailHandler(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	user, err := VerifyEmail(req.Token)
+	if errors.Is(err, ErrInvalidUserToken) {
+		response.Error(c, 400, "Email verification failed", err.Error())
+		return
+	}
+	if errors.Is(err, ErrEmailTaken) {
+		response.Error(c, 409, "Email verification failed", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Email verification failed", err.Error())
+		return
+	}
+
+	response.Success(c, "Email verified", gin.H{
+		"user_id": user.ID,
+		"email":   user.Email,
+	})
+}
+
+// POST /auth/verify-email/resend
+func ResendVerificationHandler(c *gin.Context) {
+	err := ResendVerificationEmail(c.GetString("user_id"))
+	if errors.Is(err, ErrEmailAlreadyVerified) {
+		response.Error(c, 409, "Verification email not sent", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Verification email not sent", err.Error())
+		return
+	}
+	response.Success(c, "Verification email sent", nil)
+}
+
+// POST /auth/password/forgot
+func ForgotPasswordHandler(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	RequestPasswordReset(req.Email)
+
+	// Same answer whether or not the email has an account.
+	response.Success(c, "If the email is registered, a reset link has been sent", nil)
+}
+
+// POST /auth/password/reset
+func ResetPasswordHandler(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	err := ResetPassword(req.Token, req.Password)
+	var policy *PasswordPolicyError
+	if errors.As(err, &policy) || errors.Is(err, ErrInvalidUserToken) {
+		response.Error(c, 400, "Password reset failed", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Password reset failed", err.Error())
+		return
+	}
+
+	ClearAuthCookies(c)
+	response.Success(c, "Password reset, please log in again", nil)
+}
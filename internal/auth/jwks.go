@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// JWKS lists every verification key, including ones kept only for rotation.
+func JWKS() []jwk {
+	set := make([]jwk, 0, len(keys.verify))
+
+	for _, k := range keys.verify {
+		key := jwk{Kid: k.kid, Use: "sig", Alg: k.method.Alg()}
+
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			key.Kty = "RSA"
+			key.N = b64(pub.N.Bytes())
+			key.E = b64(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			key.Kty = "OKP"
+			key.Crv = "Ed25519"
+			key.X = b64(pub)
+		}
+
+		set = append(set, key)
+	}
+
+	sort.Slice(set, func(i, j int) bool { return set[i].Kid < set[j].Kid })
+	return set
+}
+
+// GET /.well-known/jwks.json
+//
+// Served in the plain JWKS shape rather than our response envelope, since
+// JWT libraries fetch it directly.
+func JWKSHandler(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, gin.H{"keys": JWKS()})
+}
@@ -6,16 +6,42 @@ import (
 	"fraud-detection-backend/internal/config"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(userID string, role string) (string, error) {
+func GenerateToken(userID string, role string, sessionID string, mfa bool) (string, error) {
 	claims := jwt.MapClaims{
+		"iss":     config.AppConfig.JWTIssuer,
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(10 * time.Minute).Unix(),
+		"sid":     sessionID,
+		"mfa":     mfa,
+		"jti":     uuid.NewString(),
+		"exp":     time.Now().Add(AccessTokenTTL()).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+	token := jwt.NewWithClaims(keys.method, claims)
+	token.Header["kid"] = keys.activeKID
+	return token.SignedString(keys.signer)
+}
+
+/*
+ParseToken verifies an access token. Only our asymmetric algorithms are
+accepted, and the algorithm must match the key named by kid, so a token
+cannot pick a weaker check for itself.
+*/
+func ParseToken(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenStr, claims, keyFunc,
+		jwt.WithValidMethods(validMethods),
+		jwt.WithIssuer(config.AppConfig.JWTIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
 }
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fraud-detection-backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/*
+Access tokens are signed with an asymmetric key so other services can
+verify them from the JWKS endpoint without holding a secret.
+
+JWT_KEYS_DIR holds one file per key, named after its key ID:
+
+	<kid>.pem      PKCS#8 private key (RSA or Ed25519), can sign and verify
+	<kid>.pub.pem  PKIX public key, verify only
+
+To rotate, add the new private key, point JWT_ACTIVE_KID at it, and keep
+the old key (its .pub.pem is enough) until tokens signed with it have
+expired.
+*/
+
+var (
+	ErrUnknownKey  = errors.New("unknown signing key")
+	ErrKeyMismatch = errors.New("token algorithm does not match key")
+)
+
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+type keyring struct {
+	activeKID string
+	signer    crypto.Signer
+	method    jwt.SigningMethod
+	verify    map[string]*verificationKey
+}
+
+var keys *keyring
+
+// validMethods is every algorithm we sign with; anything else is refused on parse.
+var validMethods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+}
+
+func readPEM(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	return block.Bytes, nil
+}
+
+// LoadKeys reads the keyring from JWT_KEYS_DIR. It must run before any token is issued or checked.
+func LoadKeys() error {
+	cfg := config.AppConfig
+	ring := &keyring{activeKID: cfg.JWTActiveKID, verify: map[string]*verificationKey{}}
+	signers := map[string]crypto.Signer{}
+
+	if cfg.JWTKeysDir != "" {
+		entries, err := os.ReadDir(cfg.JWTKeysDir)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		for _, e := range entries {
+			name := e.Name()
+			path := filepath.Join(cfg.JWTKeysDir, name)
+
+			switch {
+			case strings.HasSuffix(name, ".pub.pem"):
+				der, err := readPEM(path)
+				if err != nil {
+					return err
+				}
+				public, err := x509.ParsePKIXPublicKey(der)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				if err := ring.addVerifier(strings.TrimSuffix(name, ".pub.pem"), public); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+
+			case strings.HasSuffix(name, ".pem"):
+				der, err := readPEM(path)
+				if err != nil {
+					return err
+				}
+				private, err := x509.ParsePKCS8PrivateKey(der)
+				if err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				signer, ok := private.(crypto.Signer)
+				if !ok {
+					return fmt.Errorf("%s: key cannot sign", path)
+				}
+				kid := strings.TrimSuffix(name, ".pem")
+				if err := ring.addVerifier(kid, signer.Public()); err != nil {
+					return fmt.Errorf("%s: %w", path, err)
+				}
+				signers[kid] = signer
+			}
+		}
+	}
+
+	if len(signers) == 0 {
+		if !cfg.AllowsDevSecrets() {
+			return fmt.Errorf("no private keys in %q; an ephemeral key is only used when APP_ENV is %s or %s",
+				cfg.JWTKeysDir, config.EnvDevelopment, config.EnvTest)
+		}
+
+		// Tokens from this key die with the process; fine for local runs only.
+		_, private, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			return err
+		}
+		ring.activeKID = "dev-ephemeral"
+		if err := ring.addVerifier(ring.activeKID, private.Public()); err != nil {
+			return err
+		}
+		signers[ring.activeKID] = private
+		log.Println("⚠️ No JWT keys found, using an ephemeral Ed25519 key")
+	}
+
+	signer, ok := signers[ring.activeKID]
+	if !ok {
+		return fmt.Errorf("active signing key %q not found in %s", ring.activeKID, cfg.JWTKeysDir)
+	}
+	ring.signer = signer
+	ring.method = ring.verify[ring.activeKID].method
+
+	keys = ring
+	log.Printf("🔑 JWT keys loaded: signing with %s (%s), %d verification keys\n",
+		ring.activeKID, ring.method.Alg(), len(ring.verify))
+	return nil
+}
+
+func (r *keyring) addVerifier(kid string, public crypto.PublicKey) error {
+	method, err := methodFor(public)
+	if err != nil {
+		return err
+	}
+	r.verify[kid] = &verificationKey{kid: kid, method: method, public: public}
+	return nil
+}
+
+// keyFunc picks the verification key named by the token's kid header.
+func keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, ok := keys.verify[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if t.Method.Alg() != key.method.Alg() {
+		return nil, ErrKeyMismatch
+	}
+	return key.public, nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fraud-detection-backend/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestMethodFor(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		public interface{}
+		want   jwt.SigningMethod
+	}{
+		{"rsa 2048", &rsaKey.PublicKey, jwt.SigningMethodRS256},
+		{"ed25519", edPublic, jwt.SigningMethodEdDSA},
+		{"rsa 1024", &weakRSA.PublicKey, nil},
+		{"ecdsa", &ecKey.PublicKey, nil},
+	}
+
+	for _, tt := range tests {
+		got, err := methodFor(tt.public)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("%s: method = %v, want an error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got.Alg() != tt.want.Alg() {
+			t.Errorf("%s: method = %v, %v; want %s", tt.name, got, err, tt.want.Alg())
+		}
+	}
+}
+
+func TestKeyFunc(t *testing.T) {
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved := keys
+	t.Cleanup(func() { keys = saved })
+	keys = &keyring{verify: map[string]*verificationKey{}}
+	if err := keys.addVerifier("k1", edPublic); err != nil {
+		t.Fatal(err)
+	}
+
+	token := func(method jwt.SigningMethod, kid interface{}) *jwt.Token {
+		tok := jwt.New(method)
+		tok.Header["kid"] = kid
+		return tok
+	}
+
+	got, err := keyFunc(token(jwt.SigningMethodEdDSA, "k1"))
+	if err != nil {
+		t.Fatalf("keyFunc: %v", err)
+	}
+	if pub, ok := got.(ed25519.PublicKey); !ok || !pub.Equal(edPublic) {
+		t.Errorf("keyFunc returned %T, want the k1 public key", got)
+	}
+
+	if _, err := keyFunc(token(jwt.SigningMethodEdDSA, "k2")); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown kid: err = %v, want ErrUnknownKey", err)
+	}
+	if _, err := keyFunc(token(jwt.SigningMethodEdDSA, 7)); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("non-string kid: err = %v, want ErrUnknownKey", err)
+	}
+	if _, err := keyFunc(token(jwt.SigningMethodRS256, "k1")); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("wrong algorithm: err = %v, want ErrKeyMismatch", err)
+	}
+}
+
+func TestLoadKeysWithoutKeysOnlyInDevelopment(t *testing.T) {
+	saved, savedConfig := keys, config.AppConfig
+	t.Cleanup(func() { keys, config.AppConfig = saved, savedConfig })
+
+	for env, allowed := range map[string]bool{
+		"":                    false,
+		"production":          false,
+		"staging":             false,
+		config.EnvDevelopment: true,
+		config.EnvTest:        true,
+	} {
+		config.AppConfig = &config.Config{AppEnv: env, JWTKeysDir: t.TempDir()}
+
+		err := LoadKeys()
+		if allowed && err != nil {
+			t.Errorf("APP_ENV=%q: %v, want an ephemeral key", env, err)
+		}
+		if !allowed && err == nil {
+			t.Errorf("APP_ENV=%q: loaded without keys, want an error", env)
+		}
+	}
+}
+
+func TestLoadKeysFromDir(t *testing.T) {
+	saved, savedConfig := keys, config.AppConfig
+	t.Cleanup(func() { keys, config.AppConfig = saved, savedConfig })
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "k1.pem"), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	config.AppConfig = &config.Config{AppEnv: "production", JWTKeysDir: dir, JWTActiveKID: "k1"}
+	if err := LoadKeys(); err != nil {
+		t.Fatalf("LoadKeys: %v", err)
+	}
+	if keys.activeKID != "k1" || keys.method.Alg() != jwt.SigningMethodEdDSA.Alg() {
+		t.Errorf("active key = %s (%s), want k1 (EdDSA)", keys.activeKID, keys.method.Alg())
+	}
+}
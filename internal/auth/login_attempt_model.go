@@ -0,0 +1,58 @@
+package auth
+
+import "time"
+
+const (
+	LoginAllowed        = "ALLOWED"
+	LoginFailed         = "FAILED"
+	LoginStepUpRequired = "STEP_UP_REQUIRED"
+	LoginStepUpPassed   = "STEP_UP_PASSED"
+	LoginMFARequired    = "MFA_REQUIRED"
+)
+
+const (
+	StepUpEmail = "EMAIL"
+	StepUpTOTP  = "TOTP"
+)
+
+/*
+LoginAttempt records every call to POST /auth/login, good password or
+not. UserID is empty when the email is unknown. Success means the
+password was right; Outcome says whether that was enough.
+*/
+type LoginAttempt struct {
+	ID            string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID        string    `gorm:"index" json:"user_id"`
+	Email         string    `gorm:"index" json:"email"`
+	DeviceID      string    `json:"device_id"`
+	IP            string    `gorm:"index" json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	Latitude      *float64  `json:"latitude,omitempty"`
+	Longitude     *float64  `json:"longitude,omitempty"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	RiskScore     int       `json:"risk_score"`
+	RiskRules     string    `json:"risk_rules"`
+	Outcome       string    `json:"outcome"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
+
+/*
+StepUpChallenge is the second step of a login that a password alone
+could not finish: a risky login confirms an emailed code (EMAIL), a user
+with MFA enters an authenticator code (TOTP). Only the hash of an
+emailed code is stored, and the challenge can only be completed from the
+device that started it.
+*/
+type StepUpChallenge struct {
+	ID         string `gorm:"type:uuid;primaryKey"`
+	UserID     string `gorm:"index;not null"`
+	AttemptID  string `gorm:"type:uuid;not null"`
+	DeviceID   string
+	Method     string `gorm:"default:EMAIL"`
+	CodeHash   string
+	Tries      int
+	ExpiresAt  time.Time
+	VerifiedAt *time.Time
+	CreatedAt  time.Time
+}
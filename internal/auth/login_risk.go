@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+)
+
+const (
+	NewDeviceLoginRule      = "NEW_DEVICE"
+	ImpossibleTravelRule    = "IMPOSSIBLE_TRAVEL"
+	CredentialStuffingRule  = "CREDENTIAL_STUFFING"
+	RepeatedFailedLoginRule = "REPEATED_FAILED_LOGINS"
+
+	// Faster than a commercial flight between two logins is not a real journey.
+	maxTravelSpeedKmh = 900
+	// GPS and geo-IP noise; shorter hops are never flagged.
+	minTravelDistanceKm = 100
+
+	stuffingWindow         = 10 * time.Minute
+	stuffingDistinctEmails = 5
+	failedLoginWindow      = 15 * time.Minute
+	failedLoginThreshold   = 5
+)
+
+// LoginContext is what we know about where a login comes from.
+type LoginContext struct {
+	DeviceID  string
+	IP        string
+	UserAgent string
+	Latitude  *float64
+	Longitude *float64
+}
+
+/*
+ScoreLogin judges a login whose password was correct. Rules mirror the
+transaction evaluator: each adds to the score, and the score decides
+whether a step-up is needed.
+*/
+func ScoreLogin(userID string, lc LoginContext, now time.Time) (int, []string) {
+	score := 0
+	var rules []string
+
+	// =================================================
+	// RULE 1: New device
+	// =================================================
+	// A user's very first login has nothing to compare against.
+	var previous, fromDevice int64
+	database.DB.Model(&LoginAttempt{}).
+		Where("user_id = ? AND success = ?", userID, true).
+		Count(&previous)
+	database.DB.Model(&LoginAttempt{}).
+		Where("user_id = ? AND success = ? AND device_id = ?", userID, true, lc.DeviceID).
+		Count(&fromDevice)
+
+	if previous > 0 && fromDevice == 0 {
+		score += 30
+		rules = append(rules, NewDeviceLoginRule)
+	}
+
+	// =================================================
+	// RULE 2: Impossible travel
+	// =================================================
+	if lc.Latitude != nil && lc.Longitude != nil {
+		var last LoginAttempt
+		err := database.DB.
+			Where("user_id = ? AND success = ? AND latitude IS NOT NULL AND longitude IS NOT NULL", userID, true).
+			Order("created_at DESC").
+			First(&last).Error
+
+		if err == nil {
+			km := distanceKm(*last.Latitude, *last.Longitude, *lc.Latitude, *lc.Longitude)
+			hours := now.Sub(last.CreatedAt).Hours()
+			if km >= minTravelDistanceKm && (hours <= 0 || km/hours > maxTravelSpeedKmh) {
+				score += 50
+				rules = append(rules, ImpossibleTravelRule)
+			}
+		}
+	}
+
+	// =================================================
+	// RULE 3: Credential stuffing
+	// =================================================
+	// One IP failing against many accounts is working through a leaked
+	// list; the account it finally gets into is the one at risk.
+	var emails int64
+	database.DB.Model(&LoginAttempt{}).
+		Where("ip = ? AND success = ? AND created_at > ?", lc.IP, false, now.Add(-stuffingWindow)).
+		Distinct("email").
+		Count(&emails)
+
+	if emails >= stuffingDistinctEmails {
+		score += 40
+		rules = append(rules, CredentialStuffingRule)
+	}
+
+	// =================================================
+	// RULE 4: Repeated failures on this account
+	// =================================================
+	var failures int64
+	database.DB.Model(&LoginAttempt{}).
+		Where("user_id = ? AND success = ? AND created_at > ?", userID, false, now.Add(-failedLoginWindow)).
+		Count(&failures)
+
+	if failures >= failedLoginThreshold {
+		score += 30
+		rules = append(rules, RepeatedFailedLoginRule)
+	}
+
+	return score, rules
+}
+
+// distanceKm is the great-circle distance between two coordinates.
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+func RecordLoginAttempt(a *LoginAttempt) error {
+	return database.DB.Create(a).Error
+}
+
+func joinRules(rules []string) string {
+	return strings.Join(rules, ",")
+}
+
+/*
+RiskyLoginSince returns the latest login since the given time that
+scored at or above the step-up threshold, or nil. Passing step-up does
+not clear it: the evaluator keeps a closer eye on the account for the
+rest of the window either way.
+*/
+func RiskyLoginSince(userID string, since time.Time) (*LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := database.DB.
+		Where("user_id = ? AND success = ? AND risk_score >= ? AND created_at >= ?",
+			userID, true, config.AppConfig.LoginStepUpThreshold, since).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&attempts).Error
+	if err != nil || len(attempts) == 0 {
+		return nil, err
+	}
+	return &attempts[0], nil
+}
+
+func LoginRiskWindow() time.Duration {
+	return time.Duration(config.AppConfig.LoginRiskWindowHours) * time.Hour
+}
+
+func GetUserLoginAttempts(userID string, limit, offset int) ([]LoginAttempt, error) {
+	var attempts []LoginAttempt
+	err := database.DB.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&attempts).Error
+	return attempts, err
+}
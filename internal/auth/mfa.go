@@ -0,0 +1,398 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	recoveryCodeCount = 10
+
+	// Wrong codes allowed before MFA checks are locked for mfaLockout.
+	mfaMaxFailures = 5
+	mfaLockout     = 15 * time.Minute
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("MFA is already enabled")
+	ErrMFANotEnrolled    = errors.New("MFA is not enrolled")
+	ErrInvalidMFACode    = errors.New("invalid MFA code")
+	ErrMFAKeyMissing     = errors.New("MFA_ENCRYPTION_KEY is not configured")
+	ErrMFALocked         = errors.New("too many wrong MFA codes, try again later")
+)
+
+// MFAEnrollment is shown to the user once, to set up their authenticator app.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+/*
+mfaCipher seals TOTP secrets at rest. When APP_ENV is development or
+test a fixed key is used if none is configured, so local databases keep
+working across restarts; anywhere else the key is required.
+*/
+func mfaCipher() (cipher.AEAD, error) {
+	var key []byte
+
+	if raw := config.AppConfig.MFAEncryptionKey; raw != "" {
+		decoded, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil || len(decoded) != 32 {
+			return nil, errors.New("MFA_ENCRYPTION_KEY must be 32 bytes, base64 encoded")
+		}
+		key = decoded
+	} else if config.AppConfig.AllowsDevSecrets() {
+		sum := sha256.Sum256([]byte("fraud-detection-dev-mfa-key"))
+		key = sum[:]
+	} else {
+		return nil, ErrMFAKeyMissing
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func sealSecret(secret string) (string, error) {
+	aead, err := mfaCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openSecret(sealed string) (string, error) {
+	aead, err := mfaCipher()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(raw) < aead.NonceSize() {
+		return "", errors.New("malformed MFA secret")
+	}
+	plain, err := aead.Open(nil, raw[:aead.NonceSize()], raw[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func findMFAFactor(userID string) (*MFAFactor, error) {
+	var factor MFAFactor
+	err := database.DB.First(&factor, "user_id = ?", userID).Error
+	return &factor, err
+}
+
+// MFAEnabled reports whether the user has a confirmed authenticator.
+func MFAEnabled(userID string) (bool, error) {
+	factor, err := findMFAFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return factor.ConfirmedAt != nil, nil
+}
+
+/*
+EnrollMFA starts (or restarts) enrollment with a new secret. The
+password is asked for again, so a stolen session alone cannot bind an
+authenticator of its own to the account.
+*/
+func EnrollMFA(userID, password string) (*MFAEnrollment, error) {
+	user, err := CheckPassword(userID, password)
+	if err != nil {
+		return nil, err
+	}
+	if enabled, err := MFAEnabled(userID); err != nil {
+		return nil, err
+	} else if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := database.DB.Save(&MFAFactor{
+		UserID:    userID,
+		Secret:    sealed,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: provisioningURI(config.AppConfig.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+/*
+ConfirmMFA turns on MFA once the user enters the password and a code
+from the new secret, and returns recovery codes. It does not make the
+current session MFA-verified: that takes a fresh login with a code.
+*/
+func ConfirmMFA(userID, password, code string) ([]string, error) {
+	if _, err := CheckPassword(userID, password); err != nil {
+		return nil, err
+	}
+
+	factor, err := findMFAFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if factor.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	if err := useTOTP(factor, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := database.DB.Model(&MFAFactor{}).
+		Where("user_id = ?", userID).
+		Update("confirmed_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logMFAChange("MFA_ENABLED", userID)
+	return codes, nil
+}
+
+// useTOTP checks code against factor and burns its time step.
+func useTOTP(factor *MFAFactor, code string) error {
+	secret, err := openSecret(factor.Secret)
+	if err != nil {
+		return err
+	}
+
+	step, err := matchTOTP(secret, strings.TrimSpace(code), time.Now(), factor.LastUsedStep)
+	if err != nil {
+		return err
+	}
+	if step == 0 {
+		return ErrInvalidMFACode
+	}
+
+	// Conditional, so two requests racing with the same code cannot both pass.
+	res := database.DB.Model(&MFAFactor{}).
+		Where("user_id = ? AND last_used_step < ?", factor.UserID, step).
+		Update("last_used_step", step)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+/*
+VerifyMFACode accepts a current TOTP code or an unused recovery code
+from a user with MFA enabled. Every flow that asks for a code goes
+through here, so they share one failure counter: after mfaMaxFailures
+wrong codes in a row, all of them are refused until the lock ends.
+*/
+func VerifyMFACode(userID, code string) error {
+	factor, err := findMFAFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && factor.ConfirmedAt == nil) {
+		return ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := reserveMFAAttempt(userID, time.Now()); err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		err = useTOTP(factor, code)
+	} else {
+		err = useRecoveryCode(userID, code)
+	}
+	if errors.Is(err, ErrInvalidMFACode) {
+		if locked, _ := findMFAFactor(userID); locked.LockedUntil != nil && locked.FailedAttempts == mfaMaxFailures {
+			logMFAChange("MFA_LOCKED", userID)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	return database.DB.Model(&MFAFactor{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"failed_attempts": 0, "locked_until": nil}).Error
+}
+
+/*
+reserveMFAAttempt counts the attempt as a failure before the code is
+checked, so parallel guesses cannot get past the limit; a good code
+resets the counter. The attempt that reaches the limit sets the lock,
+and an expired lock starts the count over.
+*/
+func reserveMFAAttempt(userID string, now time.Time) error {
+	next := "CASE WHEN locked_until IS NULL THEN failed_attempts + 1 ELSE 1 END"
+
+	res := database.DB.Model(&MFAFactor{}).
+		Where("user_id = ? AND (locked_until IS NULL OR locked_until <= ?)", userID, now).
+		Updates(map[string]interface{}{
+			"failed_attempts": gorm.Expr(next),
+			"locked_until":    gorm.Expr("CASE WHEN "+next+" >= ? THEN CAST(? AS timestamptz) END", mfaMaxFailures, now.Add(mfaLockout)),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrMFALocked
+	}
+	return nil
+}
+
+func useRecoveryCode(userID, code string) error {
+	res := database.DB.Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashToken(strings.ToLower(code))).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrInvalidMFACode
+	}
+
+	logMFAChange("MFA_RECOVERY_CODE_USED", userID)
+	return nil
+}
+
+func newRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(totpEncoding.EncodeToString(b))
+	return s[:4] + "-" + s[4:], nil
+}
+
+// replaceRecoveryCodes invalidates the old codes and returns a fresh set.
+func replaceRecoveryCodes(userID string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		rows = append(rows, RecoveryCode{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			CodeHash:  hashToken(code),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes needs a valid code, so a hijacked session alone cannot do it.
+func RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	if err := VerifyMFACode(userID, code); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logMFAChange("MFA_RECOVERY_CODES_REGENERATED", userID)
+	return codes, nil
+}
+
+// DisableMFA removes the factor and recovery codes; it also needs a valid code.
+func DisableMFA(userID, code string) error {
+	if err := VerifyMFACode(userID, code); err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&MFAFactor{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Session{}).Where("user_id = ?", userID).Update("mfa_verified", false).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// Tokens already out still say mfa=true.
+	if err := revokeIssuedTokens(userID, "MFA disabled", userID); err != nil {
+		return err
+	}
+
+	logMFAChange("MFA_DISABLED", userID)
+	return nil
+}
+
+func logMFAChange(eventType, userID string) {
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   eventType,
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: eventType,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"errors"
+
+	"fraud-detection-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type mfaEnrollRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+type mfaConfirmRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func mfaError(c *gin.Context, message string, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidMFACode), errors.Is(err, ErrWrongPassword):
+		response.Error(c, 401, message, err.Error())
+	case errors.Is(err, ErrMFALocked):
+		response.Error(c, 429, message, err.Error())
+	case errors.Is(err, ErrMFAAlreadyEnabled), errors.Is(err, ErrMFANotEnrolled):
+		response.Error(c, 409, message, err.Error())
+	default:
+		response.Error(c, 500, message, err.Error())
+	}
+}
+
+// POST /auth/mfa/enroll
+func EnrollMFAHandler(c *gin.Context) {
+	var req mfaEnrollRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	enrollment, err := EnrollMFA(c.GetString("user_id"), req.Password)
+	if err != nil {
+		mfaError(c, "MFA enrollment failed", err)
+		return
+	}
+	response.Success(c, "Scan the provisioning URI, then confirm with a code", enrollment)
+}
+
+/*
+POST /auth/mfa/confirm
+
+Turns MFA on. The current session keeps its password-only claim; MFA-only
+routes need a fresh login with a code.
+*/
+func ConfirmMFAHandler(c *gin.Context) {
+	var req mfaConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	codes, err := ConfirmMFA(c.GetString("user_id"), req.Password, req.Code)
+	if err != nil {
+		mfaError(c, "MFA confirmation failed", err)
+		return
+	}
+
+	// Recovery codes are shown once.
+	response.Success(c, "MFA enabled, sign in again with a code to reach MFA-only routes", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// POST /auth/mfa/recovery-codes
+func RegenerateRecoveryCodesHandler(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	codes, err := RegenerateRecoveryCodes(c.GetString("user_id"), req.Code)
+	if err != nil {
+		mfaError(c, "Failed to regenerate recovery codes", err)
+		return
+	}
+	response.Success(c, "Recovery codes regenerated", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// DELETE /auth/mfa
+func DisableMFAHandler(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	if err := DisableMFA(c.GetString("user_id"), req.Code); err != nil {
+		mfaError(c, "Failed to disable MFA", err)
+		return
+	}
+	response.Success(c, "MFA disabled", nil)
+}
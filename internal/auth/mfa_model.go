@@ -0,0 +1,31 @@
+package auth
+
+import "time"
+
+/*
+MFAFactor is a user's TOTP authenticator. Secret is sealed with
+MFA_ENCRYPTION_KEY. The factor only counts once ConfirmedAt is set,
+i.e. the user proved their app produces matching codes.
+
+FailedAttempts counts wrong codes since the last good one, whichever
+flow they came from; reaching the limit sets LockedUntil.
+*/
+type MFAFactor struct {
+	UserID         string `gorm:"type:uuid;primaryKey"`
+	Secret         string `gorm:"not null"`
+	LastUsedStep   int64
+	FailedAttempts int `gorm:"not null;default:0"`
+	LockedUntil    *time.Time
+	ConfirmedAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// RecoveryCode is a single-use fallback for a lost authenticator.
+type RecoveryCode struct {
+	ID        string `gorm:"type:uuid;primaryKey"`
+	UserID    string `gorm:"type:uuid;index;not null"`
+	CodeHash  string `gorm:"uniqueIndex;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
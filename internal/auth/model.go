@@ -9,4 +9,16 @@ type User struct {
 	Password  string
 	Role      string
 	CreatedAt time.Time
+
+	EmailVerifiedAt *time.Time
+	// PendingEmail is a requested new address; it replaces Email only
+	// once its verification link is opened.
+	PendingEmail string `gorm:"not null;default:''"`
+
+	// Status is one of the User* statuses. RESTRICTED users can sign in
+	// but not transact; SUSPENDED and DELETED users cannot sign in.
+	Status          string `gorm:"index;default:ACTIVE"`
+	StatusReason    string
+	StatusChangedAt *time.Time `gorm:"index"`
+	DeletedAt       *time.Time
 }
@@ -0,0 +1,227 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RoleUser    = "USER"
+	RoleAnalyst = "ANALYST"
+	RoleAuditor = "AUDITOR"
+	RoleAdmin   = "ADMIN"
+)
+
+const (
+	// Look at transactions, evaluations and merchants.
+	PermTransactionsRead = "transactions:read"
+	// Act on cases: reversals, chargeback imports, dead-letter replays.
+	PermCasesReview = "cases:review"
+	// Read the audit trail and login history.
+	PermAuditRead = "audit:read"
+	// Change what the evaluator decides, e.g. merchant risk lists.
+	PermRulesWrite = "rules:write"
+	// Roles, force-logouts and unlocks.
+	PermUsersManage = "users:manage"
+	// Webhook endpoints and other outbound integrations.
+	PermIntegrationsManage = "integrations:manage"
+)
+
+/*
+RolePermissions is the whole access model. USER has no admin
+permissions; everything under /admin needs one of these.
+*/
+var RolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleAnalyst: {
+		PermTransactionsRead,
+		PermCasesReview,
+	},
+	RoleAuditor: {
+		PermTransactionsRead,
+		PermAuditRead,
+	},
+	RoleAdmin: {
+		PermTransactionsRead,
+		PermCasesReview,
+		PermAuditRead,
+		PermRulesWrite,
+		PermUsersManage,
+		PermIntegrationsManage,
+	},
+}
+
+var (
+	ErrUnknownRole   = errors.New("unknown role")
+	ErrOwnRoleChange = errors.New("you cannot change your own role")
+	ErrLastAdmin     = errors.New("cannot remove the last admin")
+	ErrRoleUnchanged = errors.New("user already has this role")
+)
+
+func HasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+RoleGrants reports whether holding held gives everything role can do:
+the same role, or one whose permissions include all of role's. ADMIN
+therefore passes for ANALYST and AUDITOR, but AUDITOR does not pass for
+ANALYST.
+*/
+func RoleGrants(held, role string) bool {
+	if held == role {
+		return true
+	}
+	required, ok := RolePermissions[role]
+	if !ok {
+		return false
+	}
+	if _, ok := RolePermissions[held]; !ok {
+		return false
+	}
+	for _, p := range required {
+		if !HasPermission(held, p) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+AssignRole changes a user's role. Tokens already issued carry the old
+role, so they are revoked; the user's sessions stay and the next
+refresh picks up the new role.
+
+The active admins are locked before the count, so two admins demoting
+each other at the same time cannot leave nobody able to manage users.
+*/
+func AssignRole(userID, role, reason, actor string) (*User, error) {
+	if _, ok := RolePermissions[role]; !ok {
+		return nil, ErrUnknownRole
+	}
+	if userID == actor {
+		return nil, ErrOwnRoleChange
+	}
+
+	var user User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		activeAdmins, err := lockActiveAdmins(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Role == role {
+			return ErrRoleUnchanged
+		}
+		if user.Role == RoleAdmin && user.Status == UserActive && len(activeAdmins) <= 1 {
+			return ErrLastAdmin
+		}
+
+		return tx.Model(&User{}).Where("id = ?", userID).Update("role", role).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := revokeIssuedTokens(userID, "role changed", actor); err != nil {
+		return nil, err
+	}
+
+	logRoleChange(userID, user.Role, role, actor, reason)
+
+	user.Role = role
+	return &user, nil
+}
+
+/*
+lockActiveAdmins locks the active admins and returns their IDs. Every
+change that can take an admin out locks them first, always in the same
+order, so concurrent calls queue instead of deadlocking.
+*/
+func lockActiveAdmins(tx *gorm.DB) ([]string, error) {
+	var ids []string
+	err := tx.Model(&User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("role = ? AND status = ?", RoleAdmin, UserActive).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func logRoleChange(userID, from, to, actor, reason string) {
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "ROLE_CHANGED",
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: from + " -> " + to + " by " + actor + ": " + reason,
+		CreatedAt:   time.Now(),
+	})
+}
+
+/*
+BootstrapAdmin creates the first admin, or promotes an existing user,
+for the createadmin command. The address is trusted as verified since
+whoever runs the command controls the deployment.
+*/
+func BootstrapAdmin(name, email, password string) (*User, bool, error) {
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing User
+	if err := database.DB.Where("LOWER(email) = ?", email).First(&existing).Error; err == nil {
+		if existing.Role != RoleAdmin {
+			if err := database.DB.Model(&User{}).Where("id = ?", existing.ID).Update("role", RoleAdmin).Error; err != nil {
+				return nil, false, err
+			}
+			logRoleChange(existing.ID, existing.Role, RoleAdmin, "BOOTSTRAP_CLI", "createadmin")
+			existing.Role = RoleAdmin
+		}
+		return &existing, false, nil
+	}
+
+	if name == "" {
+		return nil, false, ErrInvalidName
+	}
+	if err := ValidatePassword(password); err != nil {
+		return nil, false, err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	user := &User{
+		ID:              uuid.NewString(),
+		Name:            name,
+		Email:           email,
+		Password:        hash,
+		Role:            RoleAdmin,
+		Status:          UserActive,
+		EmailVerifiedAt: &now,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		return nil, false, err
+	}
+
+	logRoleChange(user.ID, "", RoleAdmin, "BOOTSTRAP_CLI", "createadmin")
+	return user, true, nil
+}
@@ -0,0 +1,27 @@
+package auth
+
+import "testing"
+
+func TestRoleGrants(t *testing.T) {
+	tests := []struct {
+		held, role string
+		want       bool
+	}{
+		{RoleAdmin, RoleAdmin, true},
+		{RoleAdmin, RoleAnalyst, true},
+		{RoleAdmin, RoleAuditor, true},
+		{RoleAnalyst, RoleAdmin, false},
+		{RoleAuditor, RoleAnalyst, false},
+		{RoleAnalyst, RoleAuditor, false},
+		{RoleUser, RoleAnalyst, false},
+		{RoleAnalyst, RoleUser, true},
+		{"", RoleUser, false},
+		{RoleAdmin, "OWNER", false},
+	}
+
+	for _, tt := range tests {
+		if got := RoleGrants(tt.held, tt.role); got != tt.want {
+			t.Errorf("RoleGrants(%q, %q) = %v, want %v", tt.held, tt.role, got, tt.want)
+		}
+	}
+}
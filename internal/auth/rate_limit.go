@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+/*
+Per-key token buckets. They live in process memory, so with several
+instances each one enforces the limit on its own share of traffic.
+*/
+type bucket struct {
+	tokens   float64
+	capacity float64
+	updated  time.Time
+}
+
+/*
+rateLimiter holds the buckets for one kind of key, all refilling over
+the same period. A bucket left alone for a whole period is full again,
+the same as a new one, so it is dropped; that keeps keys nobody uses
+any more from piling up. maxKeys, when set, caps how many buckets are
+held at once: past it new keys are refused until old ones expire.
+*/
+type rateLimiter struct {
+	mu        sync.Mutex
+	period    time.Duration
+	maxKeys   int
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newRateLimiter(period time.Duration, maxKeys int) *rateLimiter {
+	return &rateLimiter{period: period, maxKeys: maxKeys, buckets: map[string]*bucket{}}
+}
+
+const (
+	// Password reset mails allowed per address per hour.
+	passwordResetsPerHour = 3
+	// Addresses tracked at once; anyone can ask for a reset, so this is bounded.
+	maxPasswordResetKeys = 100_000
+)
+
+var (
+	apiKeyLimiter        = newRateLimiter(time.Minute, 0)
+	passwordResetLimiter = newRateLimiter(time.Hour, maxPasswordResetKeys)
+)
+
+// AllowAPIKeyRequest takes one token from the key's bucket, or says how long until one is free.
+func AllowAPIKeyRequest(keyID string, perMinute int, now time.Time) (bool, time.Duration) {
+	return apiKeyLimiter.take(keyID, float64(perMinute), now)
+}
+
+// allowPasswordReset limits how often one address can be sent a reset link.
+func allowPasswordReset(email string, now time.Time) bool {
+	ok, _ := passwordResetLimiter.take(email, passwordResetsPerHour, now)
+	return ok
+}
+
+// take refills key's bucket at capacity tokens per period and takes one.
+func (l *rateLimiter) take(key string, capacity float64, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rate := capacity / l.period.Seconds() // tokens per second
+
+	if now.Sub(l.lastSweep) >= l.period {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok || b.capacity != capacity {
+		if !ok && l.maxKeys > 0 && len(l.buckets) >= l.maxKeys {
+			l.sweep(now)
+			if len(l.buckets) >= l.maxKeys {
+				return false, l.period
+			}
+		}
+		b = &bucket{tokens: capacity, capacity: capacity, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops the buckets that have refilled completely.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.period {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowPasswordReset(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	email := "reset-limit@example.com"
+
+	for i := 0; i < passwordResetsPerHour; i++ {
+		if !allowPasswordReset(email, now) {
+			t.Fatalf("request %d refused, want allowed", i+1)
+		}
+	}
+	if allowPasswordReset(email, now) {
+		t.Fatal("request past the hourly limit allowed")
+	}
+
+	// Other addresses have their own budget.
+	if !allowPasswordReset("someone-else@example.com", now) {
+		t.Fatal("another address was refused")
+	}
+
+	// One token comes back every 20 minutes.
+	if !allowPasswordReset(email, now.Add(20*time.Minute)) {
+		t.Fatal("request after refill refused")
+	}
+}
+
+func TestAllowAPIKeyRequestWait(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if ok, _ := AllowAPIKeyRequest("key-wait", 1, now); !ok {
+		t.Fatal("first request refused")
+	}
+	ok, wait := AllowAPIKeyRequest("key-wait", 1, now)
+	if ok || wait != time.Minute {
+		t.Fatalf("second request = %v, wait %s; want refused with 1m wait", ok, wait)
+	}
+}
+
+func TestRateLimiterDropsRefilledBuckets(t *testing.T) {
+	l := newRateLimiter(time.Hour, 0)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l.take("a", 3, now)
+	l.take("b", 3, now.Add(30*time.Minute))
+
+	// a has refilled by now, b has not.
+	l.take("c", 3, now.Add(time.Hour))
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("refilled bucket a still held")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("bucket b dropped before it refilled")
+	}
+}
+
+func TestRateLimiterMaxKeys(t *testing.T) {
+	l := newRateLimiter(time.Hour, 2)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	l.take("a", 3, now)
+	l.take("b", 3, now)
+	if ok, wait := l.take("c", 3, now); ok || wait != time.Hour {
+		t.Fatalf("third key = %v, wait %s; want refused while full", ok, wait)
+	}
+	if ok, _ := l.take("a", 3, now); !ok {
+		t.Fatal("known key refused while full")
+	}
+
+	// Once the old buckets have refilled there is room again.
+	if ok, _ := l.take("c", 3, now.Add(time.Hour)); !ok {
+		t.Fatal("new key refused after old buckets expired")
+	}
+}
@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	RevokedByAdmin = "FORCE_LOGOUT"
+
+	// Rows committed by another instance just before a sync may carry a
+	// slightly older timestamp, so each sync looks back a little further.
+	revocationSyncOverlap = 5 * time.Second
+)
+
+/*
+revocations mirrors the revocation tables in memory so AuthMiddleware
+never hits the database. Local revocations land in it immediately;
+other instances pick them up on the next SyncRevocations.
+*/
+var revocations = struct {
+	sync.RWMutex
+	tokens   map[string]time.Time // jti -> token expiry
+	sessions map[string]time.Time // session id -> last moment a token for it can be valid
+	users    map[string]time.Time // user id -> revoked-before cutoff
+	syncedAt time.Time
+}{
+	tokens:   map[string]time.Time{},
+	sessions: map[string]time.Time{},
+	users:    map[string]time.Time{},
+}
+
+// IsRevoked reports whether an otherwise valid access token must be rejected.
+func IsRevoked(jti, sessionID, userID string, issuedAt time.Time) bool {
+	revocations.RLock()
+	defer revocations.RUnlock()
+
+	if _, ok := revocations.tokens[jti]; ok && jti != "" {
+		return true
+	}
+	if _, ok := revocations.sessions[sessionID]; ok && sessionID != "" {
+		return true
+	}
+	if cutoff, ok := revocations.users[userID]; ok && !issuedAt.After(cutoff) {
+		return true
+	}
+	return false
+}
+
+func cacheSessionRevocation(sessionID string, revokedAt time.Time) {
+	revocations.Lock()
+	revocations.sessions[sessionID] = revokedAt.Add(AccessTokenTTL())
+	revocations.Unlock()
+}
+
+// RevokeToken blocks one access token, e.g. the one used to log out.
+func RevokeToken(jti, userID, reason string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+
+	err := database.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&RevokedToken{
+			JTI:       jti,
+			UserID:    userID,
+			Reason:    reason,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now(),
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	revocations.Lock()
+	revocations.tokens[jti] = expiresAt
+	revocations.Unlock()
+	return nil
+}
+
+/*
+ForceLogout ends every session of the user and rejects every token
+issued so far, for accounts we believe are taken over.
+*/
+func ForceLogout(userID, reason, actor string) (int64, error) {
+	if _, err := FindUser(userID); err != nil {
+		return 0, err
+	}
+
+	revoked, err := revokeAllSessions(userID, RevokedByAdmin, reason, actor)
+	if err != nil {
+		return 0, err
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "USER_FORCE_LOGOUT",
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: "By " + actor + ": " + reason,
+		CreatedAt:   time.Now(),
+	})
+
+	log.Println("🔒 Force logout:", userID, "sessions revoked:", revoked)
+	return revoked, nil
+}
+
+// revokeAllSessions revokes the user's sessions and every token issued up to now.
+func revokeAllSessions(userID, sessionReason, reason, actor string) (int64, error) {
+	var revoked int64
+	cutoff := tokenCutoff()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"revoked_reason": sessionReason,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		revoked = res.RowsAffected
+
+		return saveUserRevocation(tx, userID, cutoff, reason, actor)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	cacheUserRevocation(userID, cutoff)
+	return revoked, nil
+}
+
+// revokeIssuedTokens rejects every access token issued to the user up to now.
+func revokeIssuedTokens(userID, reason, actor string) error {
+	cutoff := tokenCutoff()
+	if err := saveUserRevocation(database.DB, userID, cutoff, reason, actor); err != nil {
+		return err
+	}
+
+	cacheUserRevocation(userID, cutoff)
+	return nil
+}
+
+// tokenCutoff is now, truncated to match the second-resolution iat claim.
+func tokenCutoff() time.Time {
+	return time.Now().Truncate(time.Second)
+}
+
+func saveUserRevocation(tx *gorm.DB, userID string, cutoff time.Time, reason, actor string) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"revoked_before", "reason", "revoked_by", "updated_at"}),
+	}).Create(&UserRevocation{
+		UserID:        userID,
+		RevokedBefore: cutoff,
+		Reason:        reason,
+		RevokedBy:     actor,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}).Error
+}
+
+func cacheUserRevocation(userID string, cutoff time.Time) {
+	revocations.Lock()
+	revocations.users[userID] = cutoff
+	revocations.Unlock()
+}
+
+// LoadRevocations fills the cache at startup.
+func LoadRevocations() error {
+	return syncRevocations(time.Time{})
+}
+
+// SyncRevocations pulls revocations made since the last sync, including by other instances.
+func SyncRevocations() {
+	revocations.RLock()
+	since := revocations.syncedAt.Add(-revocationSyncOverlap)
+	revocations.RUnlock()
+
+	if err := syncRevocations(since); err != nil {
+		log.Println("❌ Revocation sync failed:", err)
+	}
+}
+
+func syncRevocations(since time.Time) error {
+	now := time.Now()
+
+	var tokens []RevokedToken
+	if err := database.DB.
+		Where("created_at >= ? AND expires_at > ?", since, now).
+		Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	var sessions []Session
+	if err := database.DB.
+		Where("revoked_at >= ? AND revoked_at > ?", since, now.Add(-AccessTokenTTL())).
+		Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	var users []UserRevocation
+	if err := database.DB.
+		Where("updated_at >= ?", since).
+		Find(&users).Error; err != nil {
+		return err
+	}
+
+	var statuses []User
+	if err := database.DB.
+		Select("id, status").
+		Where("status_changed_at >= ?", since).
+		Find(&statuses).Error; err != nil {
+		return err
+	}
+	for _, u := range statuses {
+		cacheUserStatus(u.ID, u.Status)
+	}
+
+	revocations.Lock()
+	defer revocations.Unlock()
+
+	for _, t := range tokens {
+		revocations.tokens[t.JTI] = t.ExpiresAt
+	}
+	for _, s := range sessions {
+		revocations.sessions[s.ID] = s.RevokedAt.Add(AccessTokenTTL())
+	}
+	for _, u := range users {
+		revocations.users[u.UserID] = u.RevokedBefore
+	}
+
+	// Entries whose tokens have expired can no longer match anything.
+	for jti, exp := range revocations.tokens {
+		if now.After(exp) {
+			delete(revocations.tokens, jti)
+		}
+	}
+	for id, until := range revocations.sessions {
+		if now.After(until) {
+			delete(revocations.sessions, id)
+		}
+	}
+	for id, cutoff := range revocations.users {
+		if now.After(cutoff.Add(AccessTokenTTL())) {
+			delete(revocations.users, id)
+		}
+	}
+
+	revocations.syncedAt = now
+	return nil
+}
+
+// DeleteExpiredRevokedTokens drops rows for tokens that have expired on their own.
+func DeleteExpiredRevokedTokens(before time.Time) (int64, error) {
+	res := database.DB.Where("expires_at < ?", before).Delete(&RevokedToken{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,25 @@
+package auth
+
+import "time"
+
+// RevokedToken blocks a single access token until it would have expired anyway.
+type RevokedToken struct {
+	JTI       string `gorm:"primaryKey"`
+	UserID    string `gorm:"index"`
+	Reason    string
+	ExpiresAt time.Time `gorm:"index"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+/*
+UserRevocation rejects every token issued to the user at or before
+RevokedBefore. It is how a force-logout reaches tokens we never saw.
+*/
+type UserRevocation struct {
+	UserID        string `gorm:"primaryKey"`
+	RevokedBefore time.Time
+	Reason        string
+	RevokedBy     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time `gorm:"index"`
+}
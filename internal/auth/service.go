@@ -2,40 +2,203 @@ package auth
 
 import (
 	"errors"
+	"log"
+	"strings"
+	"time"
 
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/config"
 	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/notifications"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrEmailTaken = errors.New("email is already registered")
+
 func Register(name, email, password string) (*User, error) {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), 10)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidName
+	}
+
+	email, err := NormalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidatePassword(password); err != nil {
+		return nil, err
+	}
+
+	var existing int64
+	if err := database.DB.Model(&User{}).Where("LOWER(email) = ?", email).Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, ErrEmailTaken
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
 
 	user := &User{
 		ID:       uuid.NewString(),
 		Name:     name,
 		Email:    email,
-		Password: string(hash),
-		Role:     "USER",
+		Password: hash,
+		Role:     RoleUser,
+		Status:   UserActive,
 	}
 
 	if err := database.DB.Create(user).Error; err != nil {
 		return nil, err
 	}
 
+	// The account works without it; the user can ask for another link.
+	if err := SendVerificationEmail(user); err != nil {
+		log.Println("❌ Failed to send verification email:", user.ID, err)
+	}
+
 	return user, nil
 }
 
-func Login(email, password string) (*User, error) {
+// LoginResult carries either a user ready for a session or the step-up they must pass first.
+type LoginResult struct {
+	User    *User
+	Attempt *LoginAttempt
+	StepUp  *StepUpChallenge
+}
+
+/*
+Login checks the password and records the attempt. A correct password
+from a risky context returns a step-up challenge instead of a user
+ready to sign in.
+*/
+func Login(email, password string, lc LoginContext) (*LoginResult, error) {
+	now := time.Now()
+	attempt := &LoginAttempt{
+		ID:        uuid.NewString(),
+		Email:     email,
+		DeviceID:  lc.DeviceID,
+		IP:        lc.IP,
+		UserAgent: lc.UserAgent,
+		Latitude:  lc.Latitude,
+		Longitude: lc.Longitude,
+		Outcome:   LoginFailed,
+		CreatedAt: now,
+	}
+
+	// The attempt is counted as a failure before the password is even
+	// looked at, so throttled logins get nothing back and parallel
+	// guesses cannot all slip in under the same count.
+	reservation, err := ReserveLoginAttempt(email, lc.IP, now)
+	var throttled *ThrottledError
+	if errors.As(err, &throttled) {
+		attempt.FailureReason = "THROTTLED"
+		recordAttempt(attempt)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var user User
-	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := database.DB.Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(email))).First(&user).Error; err != nil {
+		attempt.FailureReason = "UNKNOWN_EMAIL"
+		recordAttempt(attempt)
+		reservation.Fail("")
 		return nil, errors.New("invalid credentials")
 	}
+	attempt.UserID = user.ID
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		attempt.FailureReason = "WRONG_PASSWORD"
+		recordAttempt(attempt)
+		reservation.Fail(user.ID)
 		return nil, errors.New("invalid credentials")
 	}
+	reservation.Release()
 
-	return &user, nil
+	// Only checked once the password is right, so it says nothing to a guesser.
+	if user.Status == UserSuspended || user.Status == UserDeleted {
+		attempt.FailureReason = "SUSPENDED"
+		recordAttempt(attempt)
+		return nil, ErrAccountSuspended
+	}
+
+	score, rules := ScoreLogin(user.ID, lc, now)
+	attempt.Success = true
+	attempt.RiskScore = score
+	attempt.RiskRules = joinRules(rules)
+	attempt.Outcome = LoginAllowed
+
+	result := &LoginResult{User: &user, Attempt: attempt}
+
+	mfa, err := MFAEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	risky := score >= config.AppConfig.LoginStepUpThreshold
+
+	// With a step-up pending the counter stays until VerifyStepUp passes.
+	if !risky && !mfa {
+		ClearAccountThrottle(email)
+		recordAttempt(attempt)
+		return result, nil
+	}
+
+	// An authenticator code is the stronger check, so it also covers a risky login.
+	method := StepUpEmail
+	attempt.Outcome = LoginStepUpRequired
+	if mfa {
+		method = StepUpTOTP
+		if !risky {
+			attempt.Outcome = LoginMFARequired
+		}
+	}
+
+	if err := RecordLoginAttempt(attempt); err != nil {
+		return nil, err
+	}
+
+	challenge, err := startStepUp(&user, attempt, method)
+	if err != nil {
+		return nil, err
+	}
+	result.StepUp = challenge
+
+	if risky {
+		audit.CreateLog(&audit.AuditLog{
+			ID:          uuid.NewString(),
+			EventType:   "LOGIN_STEP_UP_REQUIRED",
+			EntityType:  "USER",
+			EntityID:    user.ID,
+			Description: "Risky login, rules: " + attempt.RiskRules,
+			CreatedAt:   now,
+		})
+		notifications.CreateAccountNotification(
+			user.ID,
+			"RISKY_LOGIN",
+			"Unusual sign-in attempt",
+			"Someone signed in to your account from an unusual device or location. If this was not you, change your password.",
+		)
+	}
+
+	return result, nil
+}
+
+// recordAttempt never fails a login just because the attempt could not be saved.
+func recordAttempt(a *LoginAttempt) {
+	if err := RecordLoginAttempt(a); err != nil {
+		log.Println("❌ Failed to record login attempt:", err)
+	}
+}
+
+func FindUser(id string) (*User, error) {
+	var user User
+	err := database.DB.First(&user, "id = ?", id).Error
+	return &user, err
 }
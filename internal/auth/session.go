@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/config"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	RevokedByLogout = "LOGOUT"
+	RevokedByUser   = "USER_REVOKED"
+	RevokedByReuse  = "REFRESH_TOKEN_REUSE"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.AppConfig.AccessTokenTTLMinutes) * time.Minute
+}
+
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.AppConfig.RefreshTokenTTLHours) * time.Hour
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func newRefreshToken(sessionID string, now time.Time) (*RefreshToken, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(b)
+
+	return &RefreshToken{
+		ID:        uuid.NewString(),
+		SessionID: sessionID,
+		TokenHash: hashToken(raw),
+		ExpiresAt: now.Add(RefreshTokenTTL()),
+		CreatedAt: now,
+	}, raw, nil
+}
+
+// StartSession opens a session for a freshly authenticated user and returns its first refresh token.
+func StartSession(userID, deviceID, userAgent, ip string, mfaVerified bool) (*Session, string, error) {
+	now := time.Now()
+
+	session := &Session{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		DeviceID:   deviceID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(RefreshTokenTTL()),
+
+		MFAVerified: mfaVerified,
+	}
+
+	token, raw, err := newRefreshToken(session.ID, now)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := CreateSession(session, token); err != nil {
+		return nil, "", err
+	}
+
+	return session, raw, nil
+}
+
+/*
+Refresh exchanges a refresh token for a new one and returns the session
+and user to mint the next access token for.
+
+A token that was already used revokes its session: either the client
+replayed it by mistake or someone else holds a copy, and we cannot tell
+which of the two is the rightful owner.
+*/
+func Refresh(raw string) (*Session, *User, string, error) {
+	now := time.Now()
+
+	token, err := FindRefreshToken(hashToken(raw))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	session, err := FindSession(token.SessionID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !session.Active(now) || now.After(token.ExpiresAt) {
+		return nil, nil, "", ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		revokeForReuse(session)
+		return nil, nil, "", ErrRefreshTokenReused
+	}
+
+	next, nextRaw, err := newRefreshToken(session.ID, now)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	rotated, err := RotateRefreshToken(token, next, now)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if !rotated {
+		revokeForReuse(session)
+		return nil, nil, "", ErrRefreshTokenReused
+	}
+
+	user, err := FindUser(session.UserID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if user.Status == UserSuspended || user.Status == UserDeleted {
+		return nil, nil, "", ErrAccountSuspended
+	}
+
+	return session, user, nextRaw, nil
+}
+
+func revokeForReuse(session *Session) {
+	revoked, err := RevokeSession(session.ID, RevokedByReuse)
+	if err != nil {
+		log.Println("❌ Failed to revoke session after token reuse:", session.ID, err)
+		return
+	}
+	if !revoked {
+		return
+	}
+	cacheSessionRevocation(session.ID, time.Now())
+
+	log.Println("🚨 Refresh token reuse, session revoked:", session.ID)
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "SESSION_REVOKED",
+		EntityType:  "SESSION",
+		EntityID:    session.ID,
+		Description: "Refresh token reuse detected for user " + session.UserID,
+		CreatedAt:   time.Now(),
+	})
+}
+
+func FetchSessions(userID string) ([]Session, error) {
+	return GetUserSessions(userID)
+}
+
+// EndSession revokes one of the user's own sessions.
+func EndSession(userID, sessionID, reason string) error {
+	session, err := FindSession(sessionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && session.UserID != userID) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	revoked, err := RevokeSession(sessionID, reason)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		cacheSessionRevocation(sessionID, time.Now())
+	}
+	return nil
+}
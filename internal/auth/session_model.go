@@ -0,0 +1,42 @@
+package auth
+
+import "time"
+
+/*
+Session is one signed-in device. Access tokens carry its ID in the "sid"
+claim; the session itself lives as long as its refresh tokens keep
+being rotated.
+*/
+type Session struct {
+	ID            string     `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID        string     `gorm:"index;not null" json:"user_id"`
+	DeviceID      string     `gorm:"index" json:"device_id"`
+	UserAgent     string     `json:"user_agent"`
+	IP            string     `json:"ip"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+
+	// MFAVerified is set when the session was started, or later confirmed, with a second factor.
+	MFAVerified bool `json:"mfa_verified"`
+}
+
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+/*
+RefreshToken is a single-use token belonging to a session. Only the
+SHA-256 of the token is stored. Presenting a token that was already
+used means it leaked, so the whole session is revoked.
+*/
+type RefreshToken struct {
+	ID        string    `gorm:"type:uuid;primaryKey"`
+	SessionID string    `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"time"
+
+	"fraud-detection-backend/internal/database"
+
+	"gorm.io/gorm"
+)
+
+func CreateSession(s *Session, token *RefreshToken) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(s).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func FindSession(id string) (*Session, error) {
+	var s Session
+	err := database.DB.First(&s, "id = ?", id).Error
+	return &s, err
+}
+
+func FindRefreshToken(hash string) (*RefreshToken, error) {
+	var t RefreshToken
+	err := database.DB.First(&t, "token_hash = ?", hash).Error
+	return &t, err
+}
+
+func GetUserSessions(userID string) ([]Session, error) {
+	var sessions []Session
+	err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+/*
+RotateRefreshToken marks old as used, stores next and slides the session
+expiry to match it, all in one transaction. It reports false when old
+had already been used, including by a concurrent request that won the
+race.
+*/
+func RotateRefreshToken(old *RefreshToken, next *RefreshToken, now time.Time) (bool, error) {
+	rotated := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", old.ID).
+			Update("used_at", now)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&Session{}).
+			Where("id = ?", old.SessionID).
+			Updates(map[string]interface{}{
+				"last_used_at": now,
+				"expires_at":   next.ExpiresAt,
+			}).Error; err != nil {
+			return err
+		}
+
+		rotated = true
+		return nil
+	})
+
+	return rotated, err
+}
+
+// RevokeSession reports false when the session was already revoked.
+func RevokeSession(id, reason string) (bool, error) {
+	res := database.DB.Model(&Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"revoked_reason": reason,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteExpiredRefreshTokens drops tokens nobody can present any more.
+func DeleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	res := database.DB.Where("expires_at < ?", before).Delete(&RefreshToken{})
+	return res.RowsAffected, res.Error
+}
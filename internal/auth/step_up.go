@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/mailer"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	stepUpCodeTTL   = 10 * time.Minute
+	stepUpMaxTries  = 5
+	stepUpCodeDigit = 6
+)
+
+var ErrInvalidStepUp = errors.New("invalid or expired step-up code")
+
+func newStepUpCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", stepUpCodeDigit, n.Int64()), nil
+}
+
+/*
+startStepUp creates a challenge for attempt. For EMAIL it also sends the
+code; for TOTP the code comes from the user's authenticator.
+*/
+func startStepUp(user *User, attempt *LoginAttempt, method string) (*StepUpChallenge, error) {
+	challenge := &StepUpChallenge{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		AttemptID: attempt.ID,
+		DeviceID:  attempt.DeviceID,
+		Method:    method,
+		ExpiresAt: time.Now().Add(stepUpCodeTTL),
+		CreatedAt: time.Now(),
+	}
+
+	if method == StepUpTOTP {
+		if err := database.DB.Create(challenge).Error; err != nil {
+			return nil, err
+		}
+		return challenge, nil
+	}
+
+	code, err := newStepUpCode()
+	if err != nil {
+		return nil, err
+	}
+	challenge.CodeHash = hashToken(code)
+
+	if err := database.DB.Create(challenge).Error; err != nil {
+		return nil, err
+	}
+
+	if err := mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Your sign-in code",
+		Body: "Hi " + user.Name + ",\n\n" +
+			"We noticed a sign-in from an unusual device or location. Enter this code to continue:\n\n" +
+			code + "\n\n" +
+			"It expires in 10 minutes. If this was not you, change your password.",
+	}); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+/*
+VerifyStepUp completes a challenge and returns the user it belongs to,
+and whether a second factor was used. Each guess uses up a try before
+the code is compared, so parallel requests cannot get more than
+stepUpMaxTries guesses; the challenge is dead after the last one.
+
+A wrong code also counts as a failed login against the account and ip,
+and the account throttle is only cleared once the challenge passes, so
+a known password does not buy unlimited guesses at the second step.
+*/
+func VerifyStepUp(challengeID, code, deviceID, ip string) (*User, bool, error) {
+	var challenge StepUpChallenge
+	err := database.DB.First(&challenge, "id = ?", challengeID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrInvalidStepUp
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if challenge.VerifiedAt != nil ||
+		challenge.Tries >= stepUpMaxTries ||
+		time.Now().After(challenge.ExpiresAt) ||
+		challenge.DeviceID != deviceID {
+		return nil, false, ErrInvalidStepUp
+	}
+
+	user, err := FindUser(challenge.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	if err := CheckLoginThrottle(user.Email, ip, now); err != nil {
+		return nil, false, err
+	}
+
+	res := database.DB.Model(&StepUpChallenge{}).
+		Where("id = ? AND tries < ? AND verified_at IS NULL", challenge.ID, stepUpMaxTries).
+		Update("tries", gorm.Expr("tries + 1"))
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, false, ErrInvalidStepUp
+	}
+
+	var codeErr error
+	if challenge.Method == StepUpTOTP {
+		codeErr = VerifyMFACode(challenge.UserID, code)
+		if errors.Is(codeErr, ErrMFALocked) {
+			return nil, false, codeErr
+		}
+		if codeErr != nil && !errors.Is(codeErr, ErrInvalidMFACode) {
+			return nil, false, codeErr
+		}
+	} else if subtle.ConstantTimeCompare([]byte(hashToken(code)), []byte(challenge.CodeHash)) != 1 {
+		codeErr = ErrInvalidStepUp
+	}
+
+	if codeErr != nil {
+		RegisterLoginFailure(user.Email, ip, user.ID, now)
+		return nil, false, ErrInvalidStepUp
+	}
+
+	// Conditional on verified_at so a code cannot be spent twice.
+	res = database.DB.Model(&StepUpChallenge{}).
+		Where("id = ? AND verified_at IS NULL", challenge.ID).
+		Update("verified_at", now)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, false, ErrInvalidStepUp
+	}
+
+	ClearAccountThrottle(user.Email)
+
+	database.DB.Model(&LoginAttempt{}).
+		Where("id = ?", challenge.AttemptID).
+		Update("outcome", LoginStepUpPassed)
+
+	return user, challenge.Method == StepUpTOTP, nil
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/testdb"
+
+	"github.com/google/uuid"
+)
+
+const stepUpTestIP = "203.0.113.7"
+
+func setupStepUp(t *testing.T) (*User, *StepUpChallenge) {
+	testdb.Connect(t, &User{}, &StepUpChallenge{}, &LoginAttempt{}, &LoginThrottle{})
+	config.AppConfig = &config.Config{
+		LoginLockoutThreshold:   100,
+		LoginIPLockoutThreshold: 100,
+		LoginLockoutMinutes:     15,
+	}
+
+	user := &User{
+		ID:       uuid.NewString(),
+		Name:     "Step Up",
+		Email:    uuid.NewString() + "@example.com",
+		Password: "unused",
+		Role:     RoleUser,
+		Status:   UserActive,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	challenge := &StepUpChallenge{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		AttemptID: uuid.NewString(),
+		DeviceID:  "device-1",
+		Method:    StepUpEmail,
+		CodeHash:  hashToken("123456"),
+		ExpiresAt: time.Now().Add(stepUpCodeTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := database.DB.Create(challenge).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		database.DB.Delete(&StepUpChallenge{}, "id = ?", challenge.ID)
+		database.DB.Delete(&LoginThrottle{}, "key IN ?", []string{accountThrottleKey(user.Email), ipThrottleKey(stepUpTestIP)})
+		database.DB.Delete(&User{}, "id = ?", user.ID)
+	})
+	return user, challenge
+}
+
+func TestVerifyStepUpCapsParallelGuesses(t *testing.T) {
+	user, challenge := setupStepUp(t)
+
+	const guesses = 20
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := VerifyStepUp(challenge.ID, "000000", "device-1", stepUpTestIP)
+
+			var throttled *ThrottledError
+			if !errors.Is(err, ErrInvalidStepUp) && !errors.As(err, &throttled) {
+				t.Errorf("err = %v, want ErrInvalidStepUp or throttled", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var stored StepUpChallenge
+	if err := database.DB.First(&stored, "id = ?", challenge.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Tries > stepUpMaxTries {
+		t.Fatalf("tries = %d, want at most %d", stored.Tries, stepUpMaxTries)
+	}
+
+	// Every guess that got compared counted against the account.
+	var throttle LoginThrottle
+	if err := database.DB.First(&throttle, "key = ?", accountThrottleKey(user.Email)).Error; err != nil {
+		t.Fatalf("account throttle: %v", err)
+	}
+	if throttle.Failures != stored.Tries {
+		t.Errorf("account failures = %d, want %d", throttle.Failures, stored.Tries)
+	}
+}
+
+func TestVerifyStepUpSpendsCodeOnce(t *testing.T) {
+	user, challenge := setupStepUp(t)
+
+	// A failure before the good code must survive until the step-up passes.
+	if err := database.DB.Create(&LoginThrottle{
+		Key:           accountThrottleKey(user.Email),
+		Failures:      1,
+		LastFailureAt: time.Now().Add(-time.Minute),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, _, err := VerifyStepUp(challenge.ID, "123456", "device-1", stepUpTestIP)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && got.ID == user.ID:
+				passed++
+			case errors.Is(err, ErrInvalidStepUp):
+			default:
+				t.Errorf("unexpected result: %v, %v", got, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if passed != 1 {
+		t.Fatalf("passed = %d, want 1", passed)
+	}
+
+	var count int64
+	database.DB.Model(&LoginThrottle{}).Where("key = ?", accountThrottleKey(user.Email)).Count(&count)
+	if count != 0 {
+		t.Error("account throttle not cleared after the step-up passed")
+	}
+}
+
+func TestVerifyStepUpRejectsOtherDevice(t *testing.T) {
+	_, challenge := setupStepUp(t)
+
+	if _, _, err := VerifyStepUp(challenge.ID, "123456", "device-2", stepUpTestIP); !errors.Is(err, ErrInvalidStepUp) {
+		t.Fatalf("err = %v, want ErrInvalidStepUp", err)
+	}
+}
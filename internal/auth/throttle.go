@@ -0,0 +1,375 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/notifications"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/*
+Past a few failures a key goes into a cooldown before its next attempt.
+The server never sleeps on a request: an attempt made during the
+cooldown is refused with a ThrottledError (429 with Retry-After), and
+the client has to come back later.
+*/
+const (
+	// Failures older than this no longer count.
+	throttleWindow = 15 * time.Minute
+	// Free failures before each attempt has to wait.
+	throttleFreeFailures = 3
+	maxThrottleCooldown  = 30 * time.Second
+)
+
+// ThrottledError means the login was refused before the password was checked.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *ThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed attempts, locked for %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+func lockoutDuration() time.Duration {
+	return time.Duration(config.AppConfig.LoginLockoutMinutes) * time.Minute
+}
+
+// throttleCooldown doubles per failure past the free ones: 1s, 2s, 4s … capped at 30s.
+func throttleCooldown(failures int) time.Duration {
+	if failures < throttleFreeFailures {
+		return 0
+	}
+	cooldown := time.Second << uint(failures-throttleFreeFailures)
+	if cooldown <= 0 || cooldown > maxThrottleCooldown {
+		return maxThrottleCooldown
+	}
+	return cooldown
+}
+
+// stale reports whether t no longer says anything about the key.
+func (t *LoginThrottle) stale(now time.Time) bool {
+	if t.LockedUntil != nil {
+		return !now.Before(*t.LockedUntil)
+	}
+	return now.Sub(t.LastFailureAt) > throttleWindow
+}
+
+// waitFor returns how long the key must wait before its next attempt, if at all.
+func (t *LoginThrottle) waitFor(now time.Time) *ThrottledError {
+	if t.stale(now) {
+		return nil
+	}
+	if t.LockedUntil != nil {
+		return &ThrottledError{RetryAfter: t.LockedUntil.Sub(now), Locked: true}
+	}
+	if next := t.LastFailureAt.Add(throttleCooldown(t.Failures)); now.Before(next) {
+		return &ThrottledError{RetryAfter: next.Sub(now)}
+	}
+	return nil
+}
+
+// CheckLoginThrottle refuses a login while its account or IP must wait.
+func CheckLoginThrottle(email, ip string, now time.Time) error {
+	var throttles []LoginThrottle
+	if err := database.DB.
+		Where("key IN ?", []string{accountThrottleKey(email), ipThrottleKey(ip)}).
+		Find(&throttles).Error; err != nil {
+		return err
+	}
+
+	var worst *ThrottledError
+	for i := range throttles {
+		if wait := throttles[i].waitFor(now); wait != nil && (worst == nil || wait.RetryAfter > worst.RetryAfter) {
+			worst = wait
+		}
+	}
+	if worst != nil {
+		return worst
+	}
+	return nil
+}
+
+// throttleReservation is an attempt counted against key before its outcome is known.
+type throttleReservation struct {
+	key         string
+	at          time.Time
+	prevFailure time.Time
+	lockedUntil *time.Time // set when this attempt started the lockout
+}
+
+/*
+reserveAttempt counts an attempt against key as a failure up front,
+under the row lock, unless the key must wait. Parallel attempts
+therefore each see the ones before them, and no more get through than
+the cooldowns and the lockout allow. The reservation is released if
+the attempt turns out to be good.
+*/
+func reserveAttempt(key string, threshold int, now time.Time) (*throttleReservation, error) {
+	// Postgres keeps microseconds; release compares against what it stored.
+	at := now.Truncate(time.Microsecond)
+	var r *throttleReservation
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&LoginThrottle{Key: key, UpdatedAt: at}).Error; err != nil {
+			return err
+		}
+
+		var t LoginThrottle
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		if t.stale(at) {
+			t.Failures = 0
+			t.LockedUntil = nil
+		}
+		if wait := t.waitFor(at); wait != nil {
+			return wait
+		}
+
+		r = &throttleReservation{key: key, at: at, prevFailure: t.LastFailureAt}
+
+		t.Failures++
+		t.LastFailureAt = at
+		t.UpdatedAt = at
+
+		if t.Failures >= threshold {
+			until := at.Add(lockoutDuration())
+			t.LockedUntil = &until
+			r.lockedUntil = &until
+		}
+
+		return tx.Save(&t).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// release takes back an attempt that turned out to be good.
+func (r *throttleReservation) release() error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var t LoginThrottle
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, "key = ?", r.key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if t.Failures > 0 {
+			t.Failures--
+		}
+		// Only undo what nobody has overwritten since.
+		if t.LastFailureAt.Equal(r.at) {
+			t.LastFailureAt = r.prevFailure
+		}
+		if r.lockedUntil != nil && t.LockedUntil != nil && t.LockedUntil.Equal(*r.lockedUntil) {
+			t.LockedUntil = nil
+		}
+		t.UpdatedAt = time.Now()
+
+		return tx.Save(&t).Error
+	})
+}
+
+// LoginReservation holds one login attempt counted against its account and IP.
+type LoginReservation struct {
+	email, ip       string
+	account, client *throttleReservation
+}
+
+/*
+ReserveLoginAttempt counts a login against the account and the IP
+before the password is checked, and refuses it with a ThrottledError
+if either must wait. The caller either reports the failure with Fail or
+takes the attempt back with Release once the password proved right.
+*/
+func ReserveLoginAttempt(email, ip string, now time.Time) (*LoginReservation, error) {
+	account, err := reserveAttempt(accountThrottleKey(email), config.AppConfig.LoginLockoutThreshold, now)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := reserveAttempt(ipThrottleKey(ip), config.AppConfig.LoginIPLockoutThreshold, now)
+	if err != nil {
+		if relErr := account.release(); relErr != nil {
+			log.Println("❌ Failed to release login reservation:", relErr)
+		}
+		return nil, err
+	}
+
+	return &LoginReservation{email: email, ip: ip, account: account, client: client}, nil
+}
+
+// Fail keeps the attempt as a failure and reports any lockout it started.
+func (r *LoginReservation) Fail(userID string) {
+	reportLockouts(r.email, r.ip, userID, r.account.lockedUntil != nil, r.client.lockedUntil != nil, r.account.at)
+}
+
+// Release takes the attempt back from both counters.
+func (r *LoginReservation) Release() {
+	for _, res := range []*throttleReservation{r.account, r.client} {
+		if err := res.release(); err != nil {
+			log.Println("❌ Failed to release login reservation:", err)
+		}
+	}
+}
+
+// registerFailure counts one failure against key and reports whether it just got locked.
+func registerFailure(key string, threshold int, now time.Time) (bool, error) {
+	locked := false
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var t LoginThrottle
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&t, "key = ?", key).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			t = LoginThrottle{Key: key}
+		} else if err != nil {
+			return err
+		}
+
+		if t.Failures > 0 && t.stale(now) {
+			t.Failures = 0
+			t.LockedUntil = nil
+		}
+
+		t.Failures++
+		t.LastFailureAt = now
+		t.UpdatedAt = now
+
+		if t.Failures >= threshold && t.LockedUntil == nil {
+			until := now.Add(lockoutDuration())
+			t.LockedUntil = &until
+			locked = true
+		}
+
+		return tx.Save(&t).Error
+	})
+
+	return locked, err
+}
+
+/*
+RegisterLoginFailure counts a failure that was not reserved up front,
+such as a wrong step-up code, against both the account and the IP.
+*/
+func RegisterLoginFailure(email, ip, userID string, now time.Time) {
+	accountLocked, err := registerFailure(accountThrottleKey(email), config.AppConfig.LoginLockoutThreshold, now)
+	if err != nil {
+		log.Println("❌ Failed to count login failure:", err)
+	}
+	ipLocked, err := registerFailure(ipThrottleKey(ip), config.AppConfig.LoginIPLockoutThreshold, now)
+	if err != nil {
+		log.Println("❌ Failed to count login failure:", err)
+	}
+
+	reportLockouts(email, ip, userID, accountLocked, ipLocked, now)
+}
+
+/*
+reportLockouts audits a new lockout and tells the account owner, if the
+email is theirs, about it.
+*/
+func reportLockouts(email, ip, userID string, accountLocked, ipLocked bool, now time.Time) {
+	if accountLocked {
+		log.Println("🔒 Account locked after failed logins:", email)
+
+		entityID := userID
+		if entityID == "" {
+			entityID = accountThrottleKey(email)
+		}
+		audit.CreateLog(&audit.AuditLog{
+			ID:          uuid.NewString(),
+			EventType:   "ACCOUNT_LOCKED",
+			EntityType:  "USER",
+			EntityID:    entityID,
+			Description: fmt.Sprintf("Locked for %s after repeated failed logins, last from %s", lockoutDuration(), ip),
+			CreatedAt:   now,
+		})
+
+		if userID != "" {
+			notifications.CreateAccountNotification(
+				userID,
+				"ACCOUNT_LOCKED",
+				"Account temporarily locked",
+				"We locked sign-in to your account after several failed password attempts. If this was not you, reset your password once the lock ends.",
+			)
+		}
+	}
+
+	if ipLocked {
+		log.Println("🔒 IP locked after failed logins:", ip)
+
+		audit.CreateLog(&audit.AuditLog{
+			ID:          uuid.NewString(),
+			EventType:   "IP_LOCKED",
+			EntityType:  "IP",
+			EntityID:    ip,
+			Description: fmt.Sprintf("Locked for %s after repeated failed logins", lockoutDuration()),
+			CreatedAt:   now,
+		})
+	}
+}
+
+// ClearAccountThrottle resets the account counter once a login has fully succeeded.
+func ClearAccountThrottle(email string) {
+	if err := database.DB.Delete(&LoginThrottle{}, "key = ?", accountThrottleKey(email)).Error; err != nil {
+		log.Println("❌ Failed to clear login throttle:", err)
+	}
+}
+
+// UnlockAccount lifts a lockout on the user's account before it expires.
+func UnlockAccount(userID, actor string) error {
+	user, err := FindUser(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := database.DB.Delete(&LoginThrottle{}, "key = ?", accountThrottleKey(user.Email)).Error; err != nil {
+		return err
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "ACCOUNT_UNLOCKED",
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: "By " + actor,
+		CreatedAt:   time.Now(),
+	})
+	return nil
+}
+
+// DeleteStaleLoginThrottles drops counters that no longer affect anyone.
+func DeleteStaleLoginThrottles(now time.Time) (int64, error) {
+	res := database.DB.
+		Where("(locked_until IS NULL AND last_failure_at < ?) OR locked_until < ?", now.Add(-throttleWindow), now).
+		Delete(&LoginThrottle{})
+	return res.RowsAffected, res.Error
+}
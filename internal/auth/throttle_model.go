@@ -0,0 +1,17 @@
+package auth
+
+import "time"
+
+/*
+LoginThrottle counts recent failed logins for one key: an account
+("account:<email>") or a client ("ip:<address>"). Keying accounts by
+email means unknown addresses are throttled the same as real ones, so
+the lockout does not reveal which emails are registered.
+*/
+type LoginThrottle struct {
+	Key           string `gorm:"primaryKey"`
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+	UpdatedAt     time.Time
+}
@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/testdb"
+
+	"github.com/google/uuid"
+)
+
+func TestThrottleCooldown(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:  0,
+		2:  0,
+		3:  time.Second,
+		4:  2 * time.Second,
+		5:  4 * time.Second,
+		7:  16 * time.Second,
+		8:  maxThrottleCooldown,
+		40: maxThrottleCooldown,
+		90: maxThrottleCooldown,
+	}
+
+	for failures, want := range tests {
+		if got := throttleCooldown(failures); got != want {
+			t.Errorf("throttleCooldown(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestLoginThrottleWaitFor(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	lockedUntil := now.Add(10 * time.Minute)
+	expiredLock := now.Add(-time.Second)
+
+	tests := []struct {
+		name     string
+		throttle LoginThrottle
+		wait     time.Duration
+		locked   bool
+	}{
+		{"free failures", LoginThrottle{Failures: 2, LastFailureAt: now}, 0, false},
+		{"in cooldown", LoginThrottle{Failures: 4, LastFailureAt: now.Add(-500 * time.Millisecond)}, 1500 * time.Millisecond, false},
+		{"cooldown over", LoginThrottle{Failures: 4, LastFailureAt: now.Add(-3 * time.Second)}, 0, false},
+		{"outside window", LoginThrottle{Failures: 9, LastFailureAt: now.Add(-throttleWindow - time.Second)}, 0, false},
+		{"locked", LoginThrottle{Failures: 10, LastFailureAt: now, LockedUntil: &lockedUntil}, 10 * time.Minute, true},
+		{"lock expired", LoginThrottle{Failures: 10, LastFailureAt: now, LockedUntil: &expiredLock}, 0, false},
+	}
+
+	for _, tt := range tests {
+		wait := tt.throttle.waitFor(now)
+		if tt.wait == 0 {
+			if wait != nil {
+				t.Errorf("%s: wait = %+v, want none", tt.name, wait)
+			}
+			continue
+		}
+		if wait == nil || wait.RetryAfter != tt.wait || wait.Locked != tt.locked {
+			t.Errorf("%s: wait = %+v, want %s locked=%v", tt.name, wait, tt.wait, tt.locked)
+		}
+	}
+}
+
+func setupThrottle(t *testing.T, threshold int) (string, string) {
+	testdb.Connect(t, &LoginThrottle{})
+	config.AppConfig = &config.Config{
+		LoginLockoutThreshold:   threshold,
+		LoginIPLockoutThreshold: 1000,
+		LoginLockoutMinutes:     15,
+	}
+
+	email := uuid.NewString() + "@example.com"
+	ip := "198.51.100." + uuid.NewString()[:3]
+	t.Cleanup(func() {
+		database.DB.Delete(&LoginThrottle{}, "key IN ?", []string{accountThrottleKey(email), ipThrottleKey(ip)})
+	})
+	return email, ip
+}
+
+func TestReserveLoginAttemptRace(t *testing.T) {
+	email, ip := setupThrottle(t, 1000)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved := 0
+
+	now := time.Now()
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ReserveLoginAttempt(email, ip, now)
+
+			var throttled *ThrottledError
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				reserved++
+			case errors.As(err, &throttled):
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Everyone saw the same clock, so only the free failures get through.
+	if reserved != throttleFreeFailures {
+		t.Fatalf("reserved = %d, want %d", reserved, throttleFreeFailures)
+	}
+}
+
+func TestReleaseUndoesReservation(t *testing.T) {
+	email, ip := setupThrottle(t, 2)
+	now := time.Now()
+
+	if _, err := ReserveLoginAttempt(email, ip, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// The second attempt reaches the threshold and starts the lockout...
+	r, err := ReserveLoginAttempt(email, ip, now.Add(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var throttle LoginThrottle
+	database.DB.First(&throttle, "key = ?", accountThrottleKey(email))
+	if throttle.LockedUntil == nil {
+		t.Fatal("threshold reached but account not locked")
+	}
+
+	// ...which a correct password takes back.
+	r.Release()
+
+	throttle = LoginThrottle{}
+	database.DB.First(&throttle, "key = ?", accountThrottleKey(email))
+	if throttle.Failures != 1 || throttle.LockedUntil != nil {
+		t.Fatalf("after release: failures = %d, locked = %v, want 1 and unlocked", throttle.Failures, throttle.LockedUntil)
+	}
+	if !throttle.LastFailureAt.Equal(now.Truncate(time.Microsecond)) {
+		t.Errorf("last failure = %s, want %s", throttle.LastFailureAt, now)
+	}
+}
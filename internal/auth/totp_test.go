@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+)
+
+// The shared secret from RFC 4226 appendix D and RFC 6238 appendix B.
+const rfcSecret = "12345678901234567890"
+
+func TestHOTP(t *testing.T) {
+	want := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+
+	for counter, code := range want {
+		if got := hotp([]byte(rfcSecret), int64(counter)); got != code {
+			t.Errorf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	secret := totpEncoding.EncodeToString([]byte(rfcSecret))
+
+	// RFC 6238 SHA1 vectors, cut to our 6 digits.
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, code := range vectors {
+		now := time.Unix(unix, 0)
+		step, err := matchTOTP(secret, code, now, 0)
+		if err != nil || step != totpStep(now) {
+			t.Errorf("t=%d: step = %d, %v; want %d", unix, step, err, totpStep(now))
+		}
+	}
+
+	now := time.Unix(1111111109, 0)
+	tests := []struct {
+		name     string
+		secret   string
+		now      time.Time
+		lastStep int64
+		matched  bool
+	}{
+		{"lower case secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", now, 0, true},
+		{"one step late", secret, now.Add(totpPeriod * time.Second), 0, true},
+		{"one step early", secret, now.Add(-totpPeriod * time.Second), 0, true},
+		{"two steps late", secret, now.Add(2 * totpPeriod * time.Second), 0, false},
+		{"replayed", secret, now, totpStep(now), false},
+	}
+	for _, tt := range tests {
+		step, err := matchTOTP(tt.secret, "081804", tt.now, tt.lastStep)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if matched := step != 0; matched != tt.matched {
+			t.Errorf("%s: step = %d, want matched=%v", tt.name, step, tt.matched)
+		}
+	}
+
+	if _, err := matchTOTP("not base32!", "081804", now, 0); err == nil {
+		t.Error("bad secret: want an error")
+	}
+}
+
+func TestMFACipherWithoutKeyOnlyInDevelopment(t *testing.T) {
+	saved := config.AppConfig
+	t.Cleanup(func() { config.AppConfig = saved })
+
+	for env, allowed := range map[string]bool{
+		"":                    false,
+		"production":          false,
+		"staging":             false,
+		config.EnvDevelopment: true,
+		config.EnvTest:        true,
+	} {
+		config.AppConfig = &config.Config{AppEnv: env}
+
+		_, err := mfaCipher()
+		if allowed && err != nil {
+			t.Errorf("APP_ENV=%q: %v, want the development key", env, err)
+		}
+		if !allowed && !errors.Is(err, ErrMFAKeyMissing) {
+			t.Errorf("APP_ENV=%q: err = %v, want ErrMFAKeyMissing", env, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/notifications"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrUserRestricted  = errors.New("account is restricted from making transactions")
+	ErrUnknownStatus   = errors.New("status must be ACTIVE, RESTRICTED or SUSPENDED")
+	ErrStatusUnchanged = errors.New("user already has this status")
+	ErrStatusChanged   = errors.New("user status changed in the meantime")
+)
+
+/*
+userStatuses holds the status of every user who is not ACTIVE, so
+AuthMiddleware can turn away suspended users without a query. It is
+filled and kept in step by the revocation sync.
+*/
+var userStatuses = struct {
+	sync.RWMutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// UserStatus is the cached status of the user; unknown users are ACTIVE.
+func UserStatus(userID string) string {
+	userStatuses.RLock()
+	defer userStatuses.RUnlock()
+
+	if status, ok := userStatuses.m[userID]; ok {
+		return status
+	}
+	return UserActive
+}
+
+func cacheUserStatus(userID, status string) {
+	userStatuses.Lock()
+	defer userStatuses.Unlock()
+
+	if status == UserActive {
+		delete(userStatuses.m, userID)
+		return
+	}
+	userStatuses.m[userID] = status
+}
+
+/*
+ChangeUserStatus moves the user to status and records why in the
+history. Suspending also ends every session, so the user is out
+straight away rather than when their access token expires. The last
+active admin cannot be restricted or suspended.
+*/
+func ChangeUserStatus(userID, status, reason, actor string) (*User, error) {
+	return ChangeUserStatusFrom(userID, "", status, reason, actor)
+}
+
+/*
+ChangeUserStatusFrom is ChangeUserStatus for callers that decided on
+the change from the status they read earlier, such as auto-restriction:
+it fails with ErrStatusChanged unless the user still has status from,
+so an admin's suspension made in between is not overwritten. An empty
+from accepts any status.
+
+The active admins and then the user are locked for the check and the
+update, as in AssignRole.
+*/
+func ChangeUserStatusFrom(userID, from, status, reason, actor string) (*User, error) {
+	switch status {
+	case UserActive, UserRestricted, UserSuspended:
+	default:
+		return nil, ErrUnknownStatus
+	}
+
+	var user User
+	now := time.Now()
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		activeAdmins, err := lockActiveAdmins(tx)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		if user.Status == UserDeleted {
+			return gorm.ErrRecordNotFound
+		}
+		if user.Status == status {
+			return ErrStatusUnchanged
+		}
+		if from != "" && user.Status != from {
+			return ErrStatusChanged
+		}
+		if status != UserActive && user.Role == RoleAdmin && user.Status == UserActive && len(activeAdmins) <= 1 {
+			return ErrLastAdmin
+		}
+		from = user.Status
+
+		err = tx.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"status":            status,
+			"status_reason":     reason,
+			"status_changed_at": now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(&UserStatusChange{
+			ID:         uuid.NewString(),
+			UserID:     userID,
+			FromStatus: from,
+			ToStatus:   status,
+			Reason:     reason,
+			Actor:      actor,
+			CreatedAt:  now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cacheUserStatus(userID, status)
+	user.Status = status
+	user.StatusReason = reason
+	user.StatusChangedAt = &now
+
+	if status == UserSuspended {
+		if _, err := revokeAllSessions(userID, RevokedBySuspension, "suspended: "+reason, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "USER_STATUS_CHANGED",
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: from + " -> " + status + " by " + actor + ": " + reason,
+		CreatedAt:   now,
+	})
+	notifyStatusChange(userID, status)
+
+	return &user, nil
+}
+
+func notifyStatusChange(userID, status string) {
+	switch status {
+	case UserRestricted:
+		notifications.CreateAccountNotification(
+			userID,
+			"ACCOUNT_RESTRICTED",
+			"Account restricted",
+			"New transactions on your account are on hold while we review recent activity. Contact support if you need help.",
+		)
+	case UserActive:
+		notifications.CreateAccountNotification(
+			userID,
+			"ACCOUNT_REACTIVATED",
+			"Account restored",
+			"Your account has been restored and you can make transactions again.",
+		)
+	}
+}
+
+/*
+CheckCanTransact reads the status from the database rather than the
+cache, so a restriction applied on another instance a moment ago
+still holds.
+*/
+func CheckCanTransact(userID string) error {
+	var user User
+	if err := database.DB.Select("status").First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+
+	switch user.Status {
+	case UserRestricted:
+		return ErrUserRestricted
+	case UserSuspended, UserDeleted:
+		return ErrAccountSuspended
+	}
+	return nil
+}
+
+func GetUserStatusHistory(userID string) ([]UserStatusChange, error) {
+	var changes []UserStatusChange
+	err := database.DB.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&changes).Error
+	return changes, err
+}
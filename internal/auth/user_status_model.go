@@ -0,0 +1,14 @@
+package auth
+
+import "time"
+
+// UserStatusChange is one entry in a user's status history.
+type UserStatusChange struct {
+	ID         string    `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     string    `gorm:"index;not null" json:"user_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
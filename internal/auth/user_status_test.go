@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/testdb"
+
+	"github.com/google/uuid"
+)
+
+func TestChangeUserStatusFromKeepsSuspension(t *testing.T) {
+	testdb.Connect(t, &User{}, &UserStatusChange{})
+
+	user := &User{
+		ID:       uuid.NewString(),
+		Name:     "Suspended",
+		Email:    uuid.NewString() + "@example.com",
+		Password: "unused",
+		Role:     RoleUser,
+		Status:   UserSuspended,
+	}
+	if err := database.DB.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Delete(&User{}, "id = ?", user.ID) })
+
+	// Auto-restriction read ACTIVE before an admin suspended the user.
+	_, err := ChangeUserStatusFrom(user.ID, UserActive, UserRestricted, "blocked transactions", "FRAUD_EVALUATOR")
+	if !errors.Is(err, ErrStatusChanged) {
+		t.Fatalf("err = %v, want ErrStatusChanged", err)
+	}
+
+	var stored User
+	if err := database.DB.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != UserSuspended {
+		t.Errorf("status = %s, want %s", stored.Status, UserSuspended)
+	}
+}
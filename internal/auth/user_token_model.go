@@ -0,0 +1,24 @@
+package auth
+
+import "time"
+
+const (
+	TokenVerifyEmail   = "VERIFY_EMAIL"
+	TokenResetPassword = "RESET_PASSWORD"
+)
+
+/*
+UserToken is a single-use link token mailed to the user. Email is the
+address it was sent to, so a verification link stops working once the
+user changes their address.
+*/
+type UserToken struct {
+	ID        string `gorm:"type:uuid;primaryKey"`
+	UserID    string `gorm:"index;not null"`
+	Purpose   string `gorm:"index;not null"`
+	Email     string
+	TokenHash string    `gorm:"uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"index"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode"
+
+	"fraud-detection-backend/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt ignores everything past 72 bytes, so longer passwords are refused
+// rather than silently truncated.
+const maxPasswordBytes = 72
+
+var (
+	ErrInvalidEmail = errors.New("invalid email address")
+	ErrInvalidName  = errors.New("name is required")
+)
+
+// NormalizeEmail validates a bare address and returns it lowercased.
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
+
+// PasswordPolicyError lists every rule the password broke.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password does not meet policy: " + strings.Join(e.Violations, "; ")
+}
+
+// ValidatePassword checks password against the configured policy.
+func ValidatePassword(password string) error {
+	cfg := config.AppConfig
+	var violations []string
+
+	if len([]rune(password)) < cfg.PasswordMinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", cfg.PasswordMinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		violations = append(violations, fmt.Sprintf("at most %d bytes", maxPasswordBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+
+	if cfg.PasswordRequireMixedCase && !(upper && lower) {
+		violations = append(violations, "both upper and lower case letters")
+	}
+	if cfg.PasswordRequireDigit && !digit {
+		violations = append(violations, "a digit")
+	}
+	if cfg.PasswordRequireSymbol && !symbol {
+		violations = append(violations, "a symbol")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
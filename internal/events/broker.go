@@ -0,0 +1,227 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+)
+
+// Broker implementations, selected with BROKER.
+const (
+	BrokerAMQP   = "amqp"
+	BrokerMemory = "memory"
+)
+
+const (
+	TransactionCreatedQueue = "transactions.created"
+	TransactionCreatedDLQ   = "transactions.created.dlq"
+
+	// TransactionEventsExchange is the topic exchange outbound events are published to.
+	TransactionEventsExchange = "transactions.events"
+)
+
+/*
+retryDelays are the waiting rooms a failed transaction.created message
+goes through before it is dead-lettered. Each retry queue holds messages
+for its TTL and then dead-letters them back onto the main queue.
+*/
+var retryDelays = []time.Duration{
+	5 * time.Second,
+	30 * time.Second,
+	2 * time.Minute,
+}
+
+// Message is what gets published. An empty Exchange routes straight to the queue named RoutingKey.
+type Message struct {
+	ID         string
+	Exchange   string
+	RoutingKey string
+	Headers    map[string]interface{}
+	Body       []byte
+}
+
+// Delivery is a received message that must be acked or nacked exactly once.
+type Delivery struct {
+	Message
+	ack  func() error
+	nack func(requeue bool) error
+	// stale, when set, reports that the channel the message came on is
+	// gone: it can no longer be acked and the broker will redeliver it.
+	stale func() bool
+}
+
+// Stale reports whether d can no longer be acked or nacked.
+func (d Delivery) Stale() bool {
+	return d.stale != nil && d.stale()
+}
+
+func (d Delivery) Ack() error {
+	return d.ack()
+}
+
+func (d Delivery) Nack(requeue bool) error {
+	return d.nack(requeue)
+}
+
+// ExchangeSpec is a topic exchange: routing keys are dot-separated words.
+type ExchangeSpec struct {
+	Name string
+}
+
+// Binding routes messages published to Exchange whose routing key matches Pattern (* = one word, # = zero or more).
+type Binding struct {
+	Exchange string
+	Pattern  string
+}
+
+/*
+QueueSpec describes a queue independently of the broker.
+A queue with a TTL is a delay queue: messages wait there for TTL
+and then move to DeadLetterTo.
+*/
+type QueueSpec struct {
+	Name         string
+	TTL          time.Duration
+	DeadLetterTo string
+	Bindings     []Binding
+}
+
+type Topology struct {
+	Exchanges []ExchangeSpec
+	Queues    []QueueSpec
+}
+
+type Handler func(d Delivery)
+
+// Publisher returns once the broker has taken responsibility for msg.
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+/*
+Subscriber delivers messages from queue to handle, with at most prefetch
+of them unacked at a time. Subscriptions survive reconnects.
+*/
+type Subscriber interface {
+	Subscribe(queue, tag string, prefetch int, handle Handler) error
+}
+
+type Broker interface {
+	Publisher
+	Subscriber
+
+	// Connect declares topology and starts delivering. It does not block.
+	Connect(topology Topology)
+	// DeclareQueue adds a queue to the topology, e.g. for a new subscriber.
+	DeclareQueue(spec QueueSpec) error
+	// StopConsuming stops new deliveries and returns once no handler can
+	// start any more; unacked ones can still be acked.
+	StopConsuming()
+	Close()
+}
+
+var (
+	broker Broker
+
+	// inflight counts deliveries that were received but not yet acked.
+	inflight sync.WaitGroup
+)
+
+/*
+InitBroker creates the broker chosen by config.AppConfig.Broker and
+connects it. "memory" runs the whole create→score pipeline in-process,
+which is handy for local runs and tests; anything else uses RabbitMQ.
+*/
+func InitBroker() {
+	switch config.AppConfig.Broker {
+	case BrokerMemory:
+		broker = newMemoryBroker()
+	default:
+		broker = newAMQPBroker(config.AppConfig.RabbitMQURL)
+	}
+
+	broker.Connect(topology())
+	log.Println("🐇 Broker initialised:", config.AppConfig.Broker)
+}
+
+func topology() Topology {
+	t := Topology{
+		Exchanges: []ExchangeSpec{
+			{Name: TransactionEventsExchange},
+		},
+		Queues: []QueueSpec{
+			{Name: TransactionCreatedQueue},
+			{Name: TransactionCreatedDLQ},
+		},
+	}
+	for i, delay := range retryDelays {
+		t.Queues = append(t.Queues, QueueSpec{
+			Name:         retryQueueName(TransactionCreatedQueue, i+1),
+			TTL:          delay,
+			DeadLetterTo: TransactionCreatedQueue,
+		})
+	}
+	return t
+}
+
+// DeclareQueue adds a queue (and its bindings) that survives reconnects.
+func DeclareQueue(spec QueueSpec) error {
+	return broker.DeclareQueue(spec)
+}
+
+// Subscribe consumes queue with the shared in-flight accounting used by Shutdown.
+func Subscribe(queue, tag string, prefetch int, handle Handler) error {
+	return broker.Subscribe(queue, tag, prefetch, func(d Delivery) {
+		inflight.Add(1)
+		defer inflight.Done()
+
+		handle(d)
+	})
+}
+
+func retryQueueName(queue string, attempt int) string {
+	return fmt.Sprintf("%s.retry.%d", queue, attempt)
+}
+
+func publish(msg Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+
+	return broker.Publish(ctx, msg)
+}
+
+/*
+Shutdown stops taking new messages, waits for the ones already received
+to be evaluated and acked, then closes the broker. It gives up waiting
+when ctx expires; unacked messages are redelivered by the broker.
+*/
+func Shutdown(ctx context.Context) {
+	stopOutboxRelay(ctx)
+
+	if broker == nil {
+		return
+	}
+
+	// inflight.Add happens in the delivery handlers, so deliveries must
+	// have stopped before Wait starts. StopConsuming can block on a
+	// handler, which is why it runs under the same deadline.
+	drained := make(chan struct{})
+	go func() {
+		broker.StopConsuming()
+		inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		log.Println("✅ In-flight evaluations drained")
+	case <-ctx.Done():
+		log.Println("⚠️ Shutdown timed out with evaluations still in flight")
+	}
+
+	broker.Close()
+}
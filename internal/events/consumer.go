@@ -2,37 +2,241 @@ package events
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"log"
+	"time"
 
-	"fraud-detection-backend/internal/fraud"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
 )
 
-func StartTransactionConsumer() {
-	msgs, err := Channel.Consume(
-		"transactions.created",
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	if err != nil {
+const (
+	headerRetryCount = "x-retry-count"
+	headerLastError  = "x-last-error"
+	headerQueue      = "x-original-queue"
+)
+
+// Evaluator scores one transaction; correlationID is carried into the events it produces.
+type Evaluator func(txnID, correlationID string) error
+
+/*
+StartTransactionConsumer hands every transaction.created event to evaluate.
+The evaluator is injected so this package does not depend on fraud.
+
+Messages are acked only after evaluate returns. A failure (or panic) sends
+the message through the retry queues, and once those are used up, to the
+dead-letter queue.
+
+Evaluation runs on a pool of workers. Messages are sharded by user_id, so
+one user's transactions are evaluated one at a time and in order (the
+velocity and baseline rules depend on that) while different users are
+evaluated in parallel. A message that goes through a retry queue gives up
+its place in that order.
+
+After a reconnect the shard buffers may still hold deliveries from the
+lost channel while the new one sends a fresh prefetch window. Those can
+no longer be acked and the broker redelivers them, so workers drop them
+without evaluating, and the buffers are sized for both windows.
+*/
+func StartTransactionConsumer(evaluate Evaluator) {
+	workers := config.AppConfig.ConsumerWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	prefetch := config.AppConfig.ConsumerPrefetch
+	if prefetch < workers {
+		prefetch = workers
+	}
+
+	// The pool outlives broker connections; the broker restarts its
+	// subscription on reconnect and keeps feeding the same shards.
+	shards := make([]chan consumerJob, workers)
+	for i := range shards {
+		shards[i] = make(chan consumerJob, 2*prefetch)
+		go runConsumerWorker(shards[i], evaluate)
+	}
+
+	// Unacked messages on one channel are capped by prefetch, so a shard
+	// holds at most one window from the lost channel and one from the
+	// new. The dispatcher can only block if connections drop again
+	// before a worker finishes its current evaluation, and then only
+	// until it does, since the stale jobs behind it are skipped.
+	if err := broker.Subscribe(TransactionCreatedQueue, "transactions-consumer", prefetch, func(d Delivery) {
+		env, event, err := decodeTransactionCreated(d.Body)
+		if err != nil {
+			// Retrying cannot fix a body we cannot parse.
+			log.Println("Invalid event:", err)
+			deadLetter(d, err)
+			return
+		}
+
+		inflight.Add(1)
+		shards[shardFor(event.UserID, workers)] <- consumerJob{
+			delivery:      d,
+			event:         event,
+			correlationID: env.CorrelationID,
+		}
+	}); err != nil {
 		log.Fatal("Failed to start consumer:", err)
 	}
 
-	go func() {
-		for msg := range msgs {
-			var event TransactionEvent
-			if err := json.Unmarshal(msg.Body, &event); err != nil {
-				log.Println("Invalid event:", err)
-				continue
-			}
+	log.Printf("📥 Transaction consumer registered: %d workers, prefetch %d\n", workers, prefetch)
+}
+
+type consumerJob struct {
+	delivery      Delivery
+	event         TransactionEvent
+	correlationID string
+}
+
+// decodeTransactionCreated accepts any registered version, including pre-envelope bodies.
+func decodeTransactionCreated(body []byte) (*Envelope, TransactionEvent, error) {
+	var event TransactionEvent
+
+	env, err := DecodeEnvelope(body, TransactionCreatedType)
+	if err != nil {
+		return nil, event, err
+	}
+	if env.Type != TransactionCreatedType {
+		return nil, event, fmt.Errorf("%w: unexpected type %s", ErrInvalidEnvelope, env.Type)
+	}
+
+	err = json.Unmarshal(env.Payload, &event)
+	return env, event, err
+}
+
+func runConsumerWorker(jobs <-chan consumerJob, evaluate Evaluator) {
+	for job := range jobs {
+		handleConsumerJob(job, evaluate)
+	}
+}
+
+func handleConsumerJob(job consumerJob, evaluate Evaluator) {
+	defer inflight.Done()
+
+	if job.delivery.Stale() {
+		log.Println("⚠️ Dropping delivery from a lost channel, it will be redelivered:", job.event.TransactionID)
+		return
+	}
+
+	log.Println("📥 Received transaction event:", job.event.TransactionID)
+
+	// 🔥 Async fraud evaluation
+	if err := safeEvaluate(evaluate, job.event.TransactionID, job.correlationID); err != nil {
+		log.Println("❌ Fraud evaluation failed:", job.event.TransactionID, err)
+		retryOrDeadLetter(job.delivery, err)
+		return
+	}
+
+	job.delivery.Ack()
+}
 
-			log.Println("📥 Received transaction event:", event.TransactionID)
+func shardFor(userID string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return int(h.Sum32() % uint32(shards))
+}
 
-			// 🔥 Async fraud evaluation
-			fraud.EvaluateTransaction(event.TransactionID)
+func safeEvaluate(evaluate Evaluator, txnID, correlationID string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
+	return evaluate(txnID, correlationID)
+}
+
+func retryCount(d Delivery) int {
+	switch n := d.Headers[headerRetryCount].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+func retryOrDeadLetter(d Delivery, cause error) {
+	attempt := retryCount(d) + 1
+	if attempt > len(retryDelays) {
+		deadLetter(d, cause)
+		return
+	}
+
+	if err := publish(Message{
+		ID:         d.ID,
+		RoutingKey: retryQueueName(TransactionCreatedQueue, attempt),
+		Headers: map[string]interface{}{
+			headerRetryCount: int32(attempt),
+			headerLastError:  cause.Error(),
+		},
+		Body: d.Body,
+	}); err != nil {
+		// Could not park it for later; let the broker hand it back now.
+		log.Println("❌ Failed to schedule retry:", err)
+		d.Nack(true)
+		return
+	}
+
+	d.Ack()
+}
+
+func deadLetter(d Delivery, cause error) {
+	if err := publish(Message{
+		ID:         d.ID,
+		RoutingKey: TransactionCreatedDLQ,
+		Headers: map[string]interface{}{
+			headerRetryCount: int32(retryCount(d)),
+			headerLastError:  cause.Error(),
+			headerQueue:      TransactionCreatedQueue,
+		},
+		Body: d.Body,
+	}); err != nil {
+		log.Println("❌ Failed to dead-letter message:", err)
+		d.Nack(true)
+		return
+	}
+
+	log.Println("☠️ Message dead-lettered:", d.ID, cause)
+	d.Ack()
+}
+
+/*
+StartDeadLetterConsumer moves dead-lettered messages into the dead_letters
+table, where admins can inspect and replay them.
+*/
+func StartDeadLetterConsumer() {
+	if err := Subscribe(TransactionCreatedDLQ, "dead-letter-consumer", 1, storeDeadLetter); err != nil {
+		log.Fatal("Failed to start dead-letter consumer:", err)
+	}
+}
+
+func storeDeadLetter(d Delivery) {
+	lastError, _ := d.Headers[headerLastError].(string)
+	queue, _ := d.Headers[headerQueue].(string)
+	if queue == "" {
+		queue = TransactionCreatedQueue
+	}
+
+	if err := database.DB.Create(&DeadLetter{
+		ID:        uuid.NewString(),
+		MessageID: d.ID,
+		Queue:     queue,
+		Payload:   string(d.Body),
+		Error:     lastError,
+		Attempts:  retryCount(d) + 1,
+		Status:    DeadLetterDead,
+		CreatedAt: time.Now(),
+	}).Error; err != nil {
+		log.Println("❌ Failed to store dead letter:", err)
+		d.Nack(true)
+		return
+	}
+
+	d.Ack()
 }
@@ -0,0 +1,41 @@
+package events
+
+import "testing"
+
+func TestHandleConsumerJobSkipsStaleDelivery(t *testing.T) {
+	acked := false
+	job := consumerJob{
+		delivery: Delivery{
+			ack:   func() error { acked = true; return nil },
+			nack:  func(bool) error { t.Error("stale delivery nacked"); return nil },
+			stale: func() bool { return true },
+		},
+		event: TransactionEvent{TransactionID: "t1"},
+	}
+
+	evaluated := false
+	inflight.Add(1)
+	handleConsumerJob(job, func(string, string) error {
+		evaluated = true
+		return nil
+	})
+
+	if evaluated || acked {
+		t.Errorf("evaluated = %v, acked = %v; want neither for a stale delivery", evaluated, acked)
+	}
+}
+
+func TestHandleConsumerJobAcksAfterEvaluate(t *testing.T) {
+	acked := false
+	job := consumerJob{
+		delivery: Delivery{ack: func() error { acked = true; return nil }},
+		event:    TransactionEvent{TransactionID: "t1"},
+	}
+
+	inflight.Add(1)
+	handleConsumerJob(job, func(string, string) error { return nil })
+
+	if !acked {
+		t.Error("delivery not acked after a successful evaluation")
+	}
+}
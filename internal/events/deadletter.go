@@ -0,0 +1,62 @@
+package events
+
+import (
+	"errors"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+)
+
+var ErrDeadLetterReplayed = errors.New("dead letter was already replayed")
+
+func GetDeadLetters(status string, limit, offset int) ([]DeadLetter, error) {
+	var letters []DeadLetter
+	q := database.DB.Order("created_at DESC").Limit(limit).Offset(offset)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Find(&letters).Error
+	return letters, err
+}
+
+func FindDeadLetter(id string) (*DeadLetter, error) {
+	var letter DeadLetter
+	err := database.DB.First(&letter, "id = ?", id).Error
+	return &letter, err
+}
+
+/*
+ReplayDeadLetter puts a dead-lettered message back on its original queue
+with a fresh retry budget, typically after the bug that killed it is fixed.
+*/
+func ReplayDeadLetter(id, actor string) (*DeadLetter, error) {
+	letter, err := FindDeadLetter(id)
+	if err != nil {
+		return nil, err
+	}
+	if letter.Status == DeadLetterReplayed {
+		return nil, ErrDeadLetterReplayed
+	}
+
+	if err := publish(Message{
+		ID:         letter.MessageID,
+		RoutingKey: letter.Queue,
+		Body:       []byte(letter.Payload),
+	}); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := database.DB.
+		Model(&DeadLetter{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      DeadLetterReplayed,
+			"replayed_at": &now,
+			"replayed_by": actor,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	return FindDeadLetter(id)
+}
@@ -0,0 +1,23 @@
+package events
+
+import "time"
+
+// Dead letter statuses.
+const (
+	DeadLetterDead     = "DEAD"
+	DeadLetterReplayed = "REPLAYED"
+)
+
+// DeadLetter is a message that kept failing and was taken out of circulation.
+type DeadLetter struct {
+	ID         string `gorm:"primaryKey"`
+	MessageID  string `gorm:"index"`
+	Queue      string `gorm:"not null"`
+	Payload    string `gorm:"type:text;not null"`
+	Error      string `gorm:"type:text"`
+	Attempts   int
+	Status     string `gorm:"size:20;not null;index"`
+	CreatedAt  time.Time
+	ReplayedAt *time.Time
+	ReplayedBy string
+}
@@ -0,0 +1,96 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidEnvelope = errors.New("invalid event envelope")
+
+/*
+Envelope wraps every event we publish. Type and Version pick the payload
+schema; CorrelationID ties together everything caused by one request
+(the HTTP call, its transactions.created event, the decision, webhooks).
+*/
+type Envelope struct {
+	EventID       string          `json:"event_id"`
+	Type          string          `json:"type"`
+	Version       int             `json:"version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	CorrelationID string          `json:"correlation_id"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// NewEnvelope wraps payload as the latest version of eventType, validating it first.
+func NewEnvelope(eventType, correlationID string, payload interface{}) (*Envelope, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := LatestVersion(eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidatePayload(eventType, version, body); err != nil {
+		return nil, err
+	}
+
+	if correlationID == "" {
+		correlationID = uuid.NewString()
+	}
+
+	return &Envelope{
+		EventID:       uuid.NewString(),
+		Type:          eventType,
+		Version:       version,
+		OccurredAt:    time.Now().UTC(),
+		CorrelationID: correlationID,
+		Payload:       body,
+	}, nil
+}
+
+/*
+DecodeEnvelope parses and validates a consumed message and upgrades its
+payload to the latest version of its type, so handlers only ever deal
+with one shape.
+
+Bodies without an envelope predate it; they are read as version 1 of
+fallbackType, which lets a queue drain old messages during a rollout.
+*/
+func DecodeEnvelope(body []byte, fallbackType string) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+
+	if env.Type == "" && env.Version == 0 && len(env.Payload) == 0 {
+		env = Envelope{
+			Type:    fallbackType,
+			Version: 1,
+			Payload: body,
+		}
+	}
+
+	if env.Type == "" || env.Version == 0 || len(env.Payload) == 0 {
+		return nil, fmt.Errorf("%w: missing type, version or payload", ErrInvalidEnvelope)
+	}
+
+	if err := ValidatePayload(env.Type, env.Version, env.Payload); err != nil {
+		return nil, err
+	}
+
+	payload, version, err := Upcast(env.Type, env.Version, env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	env.Payload = payload
+	env.Version = version
+
+	return &env, nil
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDecodeEnvelopeUpcastsLegacyBody(t *testing.T) {
+	body := []byte(`{"transaction_id":"t1","user_id":"u1","amount":12.5,"device_id":"d1"}`)
+
+	env, err := DecodeEnvelope(body, TransactionCreatedType)
+	if err != nil {
+		t.Fatalf("DecodeEnvelope: %v", err)
+	}
+	if env.Type != TransactionCreatedType || env.Version != 2 {
+		t.Fatalf("got %s v%d, want %s v2", env.Type, env.Version, TransactionCreatedType)
+	}
+
+	var event TransactionEvent
+	if err := json.Unmarshal(env.Payload, &event); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if event.TransactionID != "t1" || event.UserID != "u1" || event.Amount != 12.5 || event.DeviceID != "d1" {
+		t.Errorf("payload = %+v", event)
+	}
+}
+
+func TestDecodeEnvelopeRoundTrip(t *testing.T) {
+	env, err := NewEnvelope(TransactionCreatedType, "corr-1", TransactionEvent{
+		TransactionID: "t1",
+		UserID:        "u1",
+		Amount:        10,
+		Currency:      "EUR",
+		DeviceID:      "d1",
+	})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DecodeEnvelope(body, TransactionCreatedType)
+	if err != nil {
+		t.Fatalf("DecodeEnvelope: %v", err)
+	}
+	if got.EventID != env.EventID || got.CorrelationID != "corr-1" || got.Version != 2 {
+		t.Errorf("got %+v, want %+v", got, env)
+	}
+}
+
+func TestDecodeEnvelopeRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"not json", `{`, ErrInvalidEnvelope},
+		{"missing payload", `{"type":"transactions.created","version":2}`, ErrInvalidEnvelope},
+		{"unknown type", `{"type":"nope","version":1,"payload":{}}`, ErrUnknownEventType},
+		{"unknown version", `{"type":"transactions.created","version":9,"payload":{}}`, ErrUnknownEventType},
+		{"legacy without user", `{"transaction_id":"t1"}`, ErrSchemaViolation},
+		{
+			"non-positive amount",
+			`{"type":"transactions.created","version":2,"payload":{"transaction_id":"t1","user_id":"u1","device_id":"d1","currency":"EUR","amount":0}}`,
+			ErrSchemaViolation,
+		},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeEnvelope([]byte(tt.body), TransactionCreatedType); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestLatestVersionWithGap(t *testing.T) {
+	const eventType = "test.gappy"
+	accept := func(json.RawMessage) error { return nil }
+
+	RegisterSchema(eventType, 3, Schema{Validate: accept})
+	RegisterSchema(eventType, 1, Schema{
+		Validate: accept,
+		Upcast:   func(p json.RawMessage) (json.RawMessage, error) { return p, nil },
+	})
+
+	latest, err := LatestVersion(eventType)
+	if err != nil {
+		t.Fatalf("LatestVersion: %v", err)
+	}
+	if latest != 3 {
+		t.Errorf("LatestVersion = %d, want 3", latest)
+	}
+
+	// v2 is missing, so v1 cannot be walked forward.
+	if _, _, err := Upcast(eventType, 1, json.RawMessage(`{}`)); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Upcast over a gap: err = %v, want ErrUnknownEventType", err)
+	}
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryQueueBuffer = 1024
+
+var errBrokerClosed = errors.New("broker is closed")
+
+type memoryQueue struct {
+	spec     QueueSpec
+	messages chan Message
+}
+
+/*
+memoryBroker is an in-process broker built on channels.
+
+It keeps the semantics the consumer relies on: unacked messages are
+capped by prefetch, nacked ones can be requeued, and delay queues hand
+their messages to DeadLetterTo once the TTL passes. Messages live in
+memory only, so a restart loses whatever was queued; the outbox still
+holds anything that was not yet published.
+*/
+type memoryBroker struct {
+	mu     sync.RWMutex
+	queues map[string]*memoryQueue
+	closed bool
+
+	stopConsuming chan struct{}
+	stopOnce      sync.Once
+	consumers     sync.WaitGroup
+}
+
+func newMemoryBroker() *memoryBroker {
+	return &memoryBroker{
+		queues:        map[string]*memoryQueue{},
+		stopConsuming: make(chan struct{}),
+	}
+}
+
+func (b *memoryBroker) Connect(topology Topology) {
+	for _, spec := range topology.Queues {
+		b.DeclareQueue(spec)
+	}
+}
+
+func (b *memoryBroker) DeclareQueue(spec QueueSpec) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if q, ok := b.queues[spec.Name]; ok {
+		q.spec.Bindings = append(q.spec.Bindings, spec.Bindings...)
+		return nil
+	}
+
+	b.queues[spec.Name] = &memoryQueue{
+		spec:     spec,
+		messages: make(chan Message, memoryQueueBuffer),
+	}
+	return nil
+}
+
+func (b *memoryBroker) queue(name string) *memoryQueue {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.queues[name]
+}
+
+// route finds the queues msg goes to: the named queue for the default exchange, bound queues otherwise.
+func (b *memoryBroker) route(msg Message) []*memoryQueue {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if msg.Exchange == "" {
+		if q, ok := b.queues[msg.RoutingKey]; ok {
+			return []*memoryQueue{q}
+		}
+		return nil
+	}
+
+	var matched []*memoryQueue
+	for _, q := range b.queues {
+		for _, binding := range q.spec.Bindings {
+			if binding.Exchange == msg.Exchange && TopicMatches(binding.Pattern, msg.RoutingKey) {
+				matched = append(matched, q)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// Publish drops unroutable messages, like RabbitMQ does.
+func (b *memoryBroker) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	closed := b.closed
+	b.mu.RUnlock()
+	if closed {
+		return errBrokerClosed
+	}
+
+	for _, q := range b.route(msg) {
+		if err := b.enqueue(ctx, q, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *memoryBroker) enqueue(ctx context.Context, q *memoryQueue, msg Message) error {
+	if q.spec.TTL > 0 {
+		time.AfterFunc(q.spec.TTL, func() {
+			msg.Exchange = ""
+			msg.RoutingKey = q.spec.DeadLetterTo
+			if err := b.Publish(context.Background(), msg); err != nil {
+				log.Println("⚠️ Memory broker failed to move delayed message:", err)
+			}
+		})
+		return nil
+	}
+
+	select {
+	case q.messages <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *memoryBroker) Subscribe(queue, tag string, prefetch int, handle Handler) error {
+	q := b.queue(queue)
+	if q == nil {
+		return errors.New("unknown queue: " + queue)
+	}
+	if prefetch < 1 {
+		prefetch = 1
+	}
+
+	// unacked is the prefetch window: a slot is taken per delivery
+	// and given back on ack or nack.
+	unacked := make(chan struct{}, prefetch)
+
+	b.consumers.Add(1)
+	go func() {
+		defer b.consumers.Done()
+
+		for {
+			select {
+			case unacked <- struct{}{}:
+			case <-b.stopConsuming:
+				return
+			}
+
+			select {
+			case msg := <-q.messages:
+				var once sync.Once
+				release := func() { once.Do(func() { <-unacked }) }
+
+				handle(Delivery{
+					Message: msg,
+					ack: func() error {
+						release()
+						return nil
+					},
+					nack: func(requeue bool) error {
+						release()
+						if requeue {
+							go func() { q.messages <- msg }()
+						}
+						return nil
+					},
+				})
+			case <-b.stopConsuming:
+				<-unacked
+				return
+			}
+		}
+	}()
+
+	log.Println("📥 Memory broker consumer started:", tag)
+	return nil
+}
+
+func (b *memoryBroker) StopConsuming() {
+	b.stopOnce.Do(func() { close(b.stopConsuming) })
+	b.consumers.Wait()
+}
+
+func (b *memoryBroker) Close() {
+	b.StopConsuming()
+
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+}
+
+// TopicMatches applies AMQP topic rules: * matches one word, # zero or more.
+func TopicMatches(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && matchWords(pattern[1:], key[1:])
+	}
+}
@@ -0,0 +1,31 @@
+package events
+
+import "testing"
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"transactions.evaluated.blocked", "transactions.evaluated.blocked", true},
+		{"transactions.evaluated.blocked", "transactions.evaluated.flagged", false},
+		{"transactions.evaluated.*", "transactions.evaluated.flagged", true},
+		{"transactions.evaluated.*", "transactions.evaluated", false},
+		{"transactions.evaluated.*", "transactions.evaluated.flagged.extra", false},
+		{"transactions.#", "transactions", true},
+		{"transactions.#", "transactions.evaluated.blocked", true},
+		{"#", "transactions.evaluated.success", true},
+		{"#.blocked", "transactions.evaluated.blocked", true},
+		{"#.blocked", "transactions.evaluated.flagged", false},
+		{"*.evaluated.#", "transactions.evaluated", true},
+		{"*.evaluated.#", "evaluated", false},
+		{"transactions.*.blocked", "transactions.evaluated.blocked", true},
+	}
+
+	for _, tt := range tests {
+		if got := TopicMatches(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("TopicMatches(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	outboxPollInterval = time.Second
+	outboxBatchSize    = 50
+	outboxMaxBackoff   = 5 * time.Minute
+	outboxMaxAttempts  = 20
+	publishTimeout     = 5 * time.Second
+
+	// outboxLease outlasts a whole batch of publishes timing out.
+	outboxLease = outboxBatchSize*publishTimeout + time.Minute
+)
+
+/*
+Enqueue wraps payload in an envelope and writes it to the outbox using tx,
+the caller's DB transaction. A payload that fails its schema is rejected
+here, before anything is committed.
+*/
+func Enqueue(
+	tx *gorm.DB,
+	aggregateID, exchange, routingKey string,
+	eventType, correlationID string,
+	payload interface{},
+) error {
+	env, err := NewEnvelope(eventType, correlationID, payload)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Create(&OutboxMessage{
+		ID:            env.EventID,
+		AggregateID:   aggregateID,
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Payload:       string(body),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}).Error
+}
+
+var (
+	relayStarted bool
+	relayStop    = make(chan struct{})
+	relayDone    = make(chan struct{})
+)
+
+// StartOutboxRelay publishes pending outbox rows in the background.
+func StartOutboxRelay() {
+	relayStarted = true
+
+	go func() {
+		defer close(relayDone)
+
+		ticker := time.NewTicker(outboxPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := relayOutboxBatch(); err != nil {
+					log.Println("❌ Outbox relay failed:", err)
+				}
+			case <-relayStop:
+				return
+			}
+		}
+	}()
+
+	log.Println("📤 Outbox relay started")
+}
+
+// stopOutboxRelay lets the batch in progress finish, then stops the relay.
+func stopOutboxRelay(ctx context.Context) {
+	if !relayStarted {
+		return
+	}
+	close(relayStop)
+
+	select {
+	case <-relayDone:
+	case <-ctx.Done():
+	}
+}
+
+/*
+claimOutboxBatch takes a lease on due rows by pushing their next attempt
+out, so publishing happens outside the DB transaction and another
+instance will not pick the same rows meanwhile. SKIP LOCKED keeps
+instances from waiting on each other's claims.
+*/
+func claimOutboxBatch() ([]OutboxMessage, error) {
+	var batch []OutboxMessage
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxPending, time.Now()).
+			Order("created_at ASC").
+			Limit(outboxBatchSize).
+			Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(batch))
+		for i, msg := range batch {
+			ids[i] = msg.ID
+		}
+
+		return tx.Model(&OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(outboxLease)).Error
+	})
+
+	return batch, err
+}
+
+/*
+relayOutboxBatch publishes the rows it claimed. A row is only marked
+SENT once the broker took it (for RabbitMQ, after the publisher
+confirm); failures are retried with exponential backoff, and a row
+that keeps failing is marked DEAD. If the relay dies mid-batch, the
+lease runs out and the rows are picked up again.
+*/
+func relayOutboxBatch() error {
+	batch, err := claimOutboxBatch()
+	if err != nil {
+		return err
+	}
+
+	for _, msg := range batch {
+		attempts := msg.Attempts + 1
+
+		if err := publish(Message{
+			ID:         msg.ID,
+			Exchange:   msg.Exchange,
+			RoutingKey: msg.RoutingKey,
+			Body:       []byte(msg.Payload),
+		}); err != nil {
+			log.Println("⚠️ Outbox publish failed:", msg.ID, "attempt", attempts, err)
+
+			updates := map[string]interface{}{
+				"attempts":        attempts,
+				"last_error":      err.Error(),
+				"next_attempt_at": time.Now().Add(outboxBackoff(attempts)),
+			}
+			if attempts >= outboxMaxAttempts {
+				updates["status"] = OutboxDead
+				log.Println("☠️ Outbox message gave up:", msg.ID)
+			}
+			if err := updateOutboxMessage(msg.ID, updates); err != nil {
+				return err
+			}
+			continue
+		}
+
+		now := time.Now()
+		if err := updateOutboxMessage(msg.ID, map[string]interface{}{
+			"status":   OutboxSent,
+			"attempts": attempts,
+			"sent_at":  &now,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func updateOutboxMessage(id string, updates map[string]interface{}) error {
+	return database.DB.Model(&OutboxMessage{}).
+		Where("id = ? AND status = ?", id, OutboxPending).
+		Updates(updates).Error
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := time.Second << uint(attempts)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
+
+// DeleteSentOutboxMessages removes rows that were published before cutoff.
+func DeleteSentOutboxMessages(cutoff time.Time) (int64, error) {
+	result := database.DB.
+		Where("status = ? AND sent_at < ?", OutboxSent, cutoff).
+		Delete(&OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,31 @@
+package events
+
+import "time"
+
+// Outbox message statuses.
+const (
+	OutboxPending = "PENDING"
+	OutboxSent    = "SENT"
+	OutboxDead    = "DEAD"
+)
+
+/*
+OutboxMessage is an event waiting to be handed to RabbitMQ.
+
+It is written in the same DB transaction as the row it describes, so an
+event exists if and only if the change was committed. The relay publishes
+it later, whether or not the broker was up when the change happened.
+*/
+type OutboxMessage struct {
+	ID            string `gorm:"primaryKey"`
+	AggregateID   string `gorm:"index"`
+	Exchange      string
+	RoutingKey    string `gorm:"not null"`
+	Payload       string `gorm:"type:text;not null"`
+	Status        string `gorm:"size:20;not null;index"`
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time `gorm:"index"`
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
@@ -2,28 +2,131 @@ package events
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
+	"time"
 
-	amqp "github.com/rabbitmq/amqp091-go"
+	"gorm.io/gorm"
 )
 
-type TransactionEvent struct {
+const (
+	TransactionCreatedType   = "transactions.created"
+	TransactionEvaluatedType = "transactions.evaluated"
+)
+
+// transactionEventV1 is the original transactions.created payload, before the envelope.
+type transactionEventV1 struct {
 	TransactionID string  `json:"transaction_id"`
 	UserID        string  `json:"user_id"`
 	Amount        float64 `json:"amount"`
 	DeviceID      string  `json:"device_id"`
 }
 
-func PublishTransactionCreated(event TransactionEvent) error {
-	body, _ := json.Marshal(event)
-
-	return Channel.Publish(
-		"",
-		"transactions.created",
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
+// TransactionEvent is the current (v2) transactions.created payload.
+type TransactionEvent struct {
+	TransactionID string    `json:"transaction_id"`
+	UserID        string    `json:"user_id"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	DeviceID      string    `json:"device_id"`
+	MerchantID    string    `json:"merchant_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+/*
+DecisionEvent tells downstream systems (ledger, notifications, analytics)
+how a transaction was decided. It is published to the transactions.events
+topic exchange with routing key transactions.evaluated.<status>, so each
+consumer can bind to just the outcomes it cares about.
+*/
+type DecisionEvent struct {
+	TransactionID string    `json:"transaction_id"`
+	UserID        string    `json:"user_id"`
+	MerchantID    string    `json:"merchant_id"`
+	Status        string    `json:"status"`
+	RiskScore     int       `json:"risk_score"`
+	Rules         []string  `json:"rules"`
+	EvaluatedAt   time.Time `json:"evaluated_at"`
+}
+
+var decisionStatuses = map[string]bool{"SUCCESS": true, "FLAGGED": true, "BLOCKED": true}
+
+func init() {
+	// v1 had no currency/merchant and producers often left device_id out.
+	RegisterSchema(TransactionCreatedType, 1, Schema{
+		Validate: func(p json.RawMessage) error {
+			return requireFields(p, "transaction_id", "user_id")
 		},
+		Upcast: func(p json.RawMessage) (json.RawMessage, error) {
+			var v1 transactionEventV1
+			if err := json.Unmarshal(p, &v1); err != nil {
+				return nil, err
+			}
+			return json.Marshal(TransactionEvent{
+				TransactionID: v1.TransactionID,
+				UserID:        v1.UserID,
+				Amount:        v1.Amount,
+				DeviceID:      v1.DeviceID,
+			})
+		},
+	})
+
+	RegisterSchema(TransactionCreatedType, 2, Schema{
+		Validate: func(p json.RawMessage) error {
+			if err := requireFields(p, "transaction_id", "user_id", "device_id", "currency"); err != nil {
+				return err
+			}
+			var e TransactionEvent
+			if err := json.Unmarshal(p, &e); err != nil {
+				return err
+			}
+			if e.Amount <= 0 {
+				return errors.New("amount must be positive")
+			}
+			return nil
+		},
+	})
+
+	RegisterSchema(TransactionEvaluatedType, 1, Schema{
+		Validate: func(p json.RawMessage) error {
+			if err := requireFields(p, "transaction_id", "user_id", "status"); err != nil {
+				return err
+			}
+			var e DecisionEvent
+			if err := json.Unmarshal(p, &e); err != nil {
+				return err
+			}
+			if !decisionStatuses[e.Status] {
+				return errors.New("status must be SUCCESS, FLAGGED or BLOCKED")
+			}
+			return nil
+		},
+	})
+}
+
+// EnqueueTransactionCreated queues a transactions.created event inside tx.
+func EnqueueTransactionCreated(tx *gorm.DB, correlationID string, event TransactionEvent) error {
+	return Enqueue(tx, event.TransactionID, "", TransactionCreatedQueue, TransactionCreatedType, correlationID, event)
+}
+
+// DecisionRoutingKey is e.g. transactions.evaluated.flagged.
+func DecisionRoutingKey(status string) string {
+	return TransactionEvaluatedType + "." + strings.ToLower(status)
+}
+
+// EnqueueTransactionEvaluated queues a decision event inside tx.
+func EnqueueTransactionEvaluated(tx *gorm.DB, correlationID string, event DecisionEvent) error {
+	if event.Rules == nil {
+		event.Rules = []string{}
+	}
+
+	return Enqueue(
+		tx,
+		event.TransactionID,
+		TransactionEventsExchange,
+		DecisionRoutingKey(event.Status),
+		TransactionEvaluatedType,
+		correlationID,
+		event,
 	)
 }
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	ErrUnknownEventType = errors.New("unknown event type")
+	ErrSchemaViolation  = errors.New("event payload does not match its schema")
+)
+
+/*
+Schema is one version of one event type.
+
+Validate checks a payload of exactly this version. Upcast, when set,
+turns it into the next version; the latest version has none.
+*/
+type Schema struct {
+	Validate func(payload json.RawMessage) error
+	Upcast   func(payload json.RawMessage) (json.RawMessage, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]map[int]Schema{}
+	latest     = map[string]int{}
+)
+
+/*
+RegisterSchema adds a version of eventType. Versions start at 1 and
+should have no gaps: Upcast walks them one at a time and fails on a
+missing one.
+*/
+func RegisterSchema(eventType string, version int, schema Schema) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if registry[eventType] == nil {
+		registry[eventType] = map[int]Schema{}
+	}
+	registry[eventType][version] = schema
+
+	if version > latest[eventType] {
+		latest[eventType] = version
+	}
+}
+
+func LatestVersion(eventType string) (int, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	version, ok := latest[eventType]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+	return version, nil
+}
+
+func lookupSchema(eventType string, version int) (Schema, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	schema, ok := registry[eventType][version]
+	if !ok {
+		return Schema{}, fmt.Errorf("%w: %s v%d", ErrUnknownEventType, eventType, version)
+	}
+	return schema, nil
+}
+
+func ValidatePayload(eventType string, version int, payload json.RawMessage) error {
+	schema, err := lookupSchema(eventType, version)
+	if err != nil {
+		return err
+	}
+	if err := schema.Validate(payload); err != nil {
+		return fmt.Errorf("%w: %s v%d: %v", ErrSchemaViolation, eventType, version, err)
+	}
+	return nil
+}
+
+// Upcast walks payload forward one version at a time until it is the latest.
+func Upcast(eventType string, version int, payload json.RawMessage) (json.RawMessage, int, error) {
+	latest, err := LatestVersion(eventType)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for version < latest {
+		schema, err := lookupSchema(eventType, version)
+		if err != nil {
+			return nil, 0, err
+		}
+		if schema.Upcast == nil {
+			return nil, 0, fmt.Errorf("no upcaster for %s v%d", eventType, version)
+		}
+		if payload, err = schema.Upcast(payload); err != nil {
+			return nil, 0, err
+		}
+		version++
+	}
+
+	return payload, version, nil
+}
+
+// requireFields is the common validation: payload is an object and the fields are non-empty.
+func requireFields(payload json.RawMessage, fields ...string) error {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(payload, &obj); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		v, ok := obj[f]
+		if !ok || v == nil || v == "" {
+			return fmt.Errorf("%s is required", f)
+		}
+	}
+	return nil
+}
@@ -5,3 +5,7 @@ const (
 	AmountDeviationMed  = "AMOUNT_DEVIATION_MEDIUM"
 	AmountDeviationHigh = "AMOUNT_DEVIATION_HIGH"
 )
+
+// ChargebackRateThreshold is the share of settled transactions that,
+// once charged back, makes a user or device high risk.
+const ChargebackRateThreshold = 0.05
@@ -1,26 +1,39 @@
 package fraud
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/auth"
 	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/events"
+	"fraud-detection-backend/internal/merchants"
 	"fraud-detection-backend/internal/notifications"
+	"fraud-detection-backend/internal/risklists"
+	"fraud-detection-backend/internal/transactions"
 )
 
 /*
 txnSnapshot holds only the data needed to judge a transaction.
 */
 type txnSnapshot struct {
-	ID        string
-	UserID    string
-	Amount    float64
-	DeviceID  string
-	CreatedAt time.Time
+	ID         string
+	UserID     string
+	Amount     float64
+	DeviceID   string
+	MerchantID string
+	Status     string
+
+	IP                string
+	PaymentIdentifier string
+	CreatedAt         time.Time
 }
 
 /*
@@ -33,13 +46,15 @@ type userStats struct {
 
 /*
 EvaluateTransaction runs asynchronously after a transaction is created.
+correlationID comes from the transactions.created event and is passed on
+to the decision event.
 
 Design decision:
 - Each user has one primary trusted device
 - New devices are NEVER auto-trusted
 - Any transaction from a different device increases risk
 */
-func EvaluateTransaction(txnID string) {
+func EvaluateTransaction(txnID, correlationID string) error {
 
 	log.Println("Fraud evaluation started for transaction:", txnID)
 
@@ -51,9 +66,182 @@ func EvaluateTransaction(txnID string) {
 		Table("transactions").
 		Where("id = ?", txnID).
 		First(&txn).Error; err != nil {
-		return
+		return fmt.Errorf("load transaction %s: %w", txnID, err)
 	}
 
+	// Redelivered or replayed events for a decided transaction are a no-op.
+	if txn.Status != transactions.StatusPending {
+		log.Println("Skipping evaluation, transaction is already", txn.Status, txnID)
+		return nil
+	}
+
+	// We trust ONLY the first device used by the user.
+	var trustedDevice string
+
+	database.DB.
+		Table("devices").
+		Select("device_id").
+		Where("user_id = ?", txn.UserID).
+		Limit(1).
+		Scan(&trustedDevice)
+
+	// =================================================
+	// Allow / deny lists
+	// =================================================
+	// An analyst's listing beats any score, so a listed transaction
+	// skips the rules. Deny wins when both lists match.
+	var riskScore int
+	var triggeredRules []string
+
+	entry, err := risklists.Match(risklists.Subject{
+		UserID:            txn.UserID,
+		DeviceID:          txn.DeviceID,
+		IP:                txn.IP,
+		PaymentIdentifier: txn.PaymentIdentifier,
+	})
+	if err != nil {
+		return fmt.Errorf("check risk lists %s: %w", txn.ID, err)
+	}
+
+	switch {
+	case entry == nil:
+		riskScore, triggeredRules = runRules(txn, trustedDevice)
+	case entry.List == risklists.Deny:
+		riskScore = 100
+		triggeredRules = []string{"DENYLIST_" + entry.Type}
+	default:
+		triggeredRules = []string{"ALLOWLIST_" + entry.Type}
+	}
+
+	// =================================================
+	// Decision
+	// =================================================
+	status := transactions.StatusSuccess
+	event := "TRANSACTION_ALLOWED"
+
+	if riskScore >= 30 && riskScore <= 70 {
+		status = transactions.StatusFlagged
+		event = "TRANSACTION_FLAGGED"
+	} else if riskScore > 70 {
+		status = transactions.StatusBlocked
+		event = "TRANSACTION_BLOCKED"
+	}
+
+	// ------------------------------------------------
+	// Update transaction
+	// ------------------------------------------------
+	// The transition is version-checked, so if the stale job (or anyone
+	// else) moved the transaction meanwhile, we stop here instead of
+	// overwriting their decision. Any other failure is retried.
+	// The evaluation row and the decision event are written in the same
+	// DB transaction, so they exist exactly when the decision sticks and a
+	// retried evaluation does not leave a second row behind.
+	if _, err := transactions.Transition(
+		txn.ID,
+		status,
+		"FRAUD_EVALUATOR",
+		"Triggered rules: "+strings.Join(triggeredRules, ","),
+		map[string]interface{}{"risk_score": riskScore},
+		func(tx *gorm.DB) error {
+			if err := tx.Table("fraud_evaluations").Create(map[string]interface{}{
+				"id":              uuid.NewString(),
+				"transaction_id":  txn.ID,
+				"risk_score":      riskScore,
+				"rules_triggered": strings.Join(triggeredRules, ","),
+				"created_at":      time.Now(),
+			}).Error; err != nil {
+				return fmt.Errorf("save fraud evaluation: %w", err)
+			}
+			return events.EnqueueTransactionEvaluated(tx, correlationID, events.DecisionEvent{
+				TransactionID: txn.ID,
+				UserID:        txn.UserID,
+				MerchantID:    txn.MerchantID,
+				Status:        status,
+				RiskScore:     riskScore,
+				Rules:         triggeredRules,
+				EvaluatedAt:   time.Now(),
+			})
+		},
+	); err != nil {
+		if errors.Is(err, transactions.ErrIllegalTransition) || errors.Is(err, transactions.ErrVersionConflict) {
+			log.Println("❌ Transaction status update rejected:", txn.ID, err)
+			return nil
+		}
+		return fmt.Errorf("update transaction %s: %w", txn.ID, err)
+	}
+
+	// =================================================
+	// Notifications
+	// =================================================
+	if status == transactions.StatusFlagged {
+		notifications.CreateTransactionNotification(
+			txn.UserID,
+			txn.ID,
+			"TXN_FLAGGED",
+			"Transaction Flagged",
+			"Your transaction was flagged due to unusual activity.",
+		)
+	}
+
+	if status == transactions.StatusBlocked {
+		notifications.CreateTransactionNotification(
+			txn.UserID,
+			txn.ID,
+			"TXN_BLOCKED",
+			"Transaction Blocked",
+			"Your transaction was blocked due to high risk.",
+		)
+		restrictRepeatOffender(txn.UserID)
+	}
+
+	// =================================================
+	// Audit log
+	// =================================================
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   event,
+		EntityType:  "TRANSACTION",
+		EntityID:    txn.ID,
+		Description: "Triggered rules: " + strings.Join(triggeredRules, ","),
+		CreatedAt:   time.Now(),
+	})
+
+	// =================================================
+	// Learn behavior ONLY on success
+	// =================================================
+	if status == transactions.StatusSuccess {
+
+		database.DB.Exec(`
+			INSERT INTO user_transaction_stats (user_id, total_txns, total_amount, avg_amount)
+			VALUES (?, 1, ?, ?)
+			ON CONFLICT (user_id)
+			DO UPDATE SET
+				total_txns = user_transaction_stats.total_txns + 1,
+				total_amount = user_transaction_stats.total_amount + EXCLUDED.total_amount,
+				avg_amount =
+					(user_transaction_stats.total_amount + EXCLUDED.total_amount)
+					/ (user_transaction_stats.total_txns + 1),
+				last_updated = NOW()
+		`, txn.UserID, txn.Amount, txn.Amount)
+	}
+
+	// =================================================
+	// Store PRIMARY device only once
+	// =================================================
+	// We store device only if user has no device yet.
+	if status == transactions.StatusSuccess && trustedDevice == "" {
+		database.DB.Table("devices").Create(map[string]interface{}{
+			"user_id":    txn.UserID,
+			"device_id":  txn.DeviceID,
+			"first_seen": time.Now(),
+		})
+	}
+
+	return nil
+}
+
+// runRules scores a transaction that is on neither list.
+func runRules(txn txnSnapshot, trustedDevice string) (int, []string) {
 	riskScore := 0
 	var triggeredRules []string
 
@@ -131,15 +319,6 @@ func EvaluateTransaction(txnID string) {
 	// =================================================
 	// We trust ONLY the first device used by the user.
 	// Any other device always adds risk.
-	var trustedDevice string
-
-	database.DB.
-		Table("devices").
-		Select("device_id").
-		Where("user_id = ?", txn.UserID).
-		Limit(1).
-		Scan(&trustedDevice)
-
 	if trustedDevice != "" && trustedDevice != txn.DeviceID {
 		riskScore += 30
 		triggeredRules = append(triggeredRules, "UNTRUSTED_DEVICE")
@@ -153,105 +332,75 @@ func EvaluateTransaction(txnID string) {
 		triggeredRules = append(triggeredRules, "MISSING_DEVICE_ID")
 	}
 
-	// ------------------------------------------------
-	// Save fraud evaluation
-	// ------------------------------------------------
-	database.DB.Table("fraud_evaluations").Create(map[string]interface{}{
-		"id":              uuid.NewString(),
-		"transaction_id":  txn.ID,
-		"risk_score":      riskScore,
-		"rules_triggered": strings.Join(triggeredRules, ","),
-		"created_at":      time.Now(),
-	})
-
 	// =================================================
-	// Decision
+	// RULE 6: Chargeback history
 	// =================================================
-	status := "SUCCESS"
-	event := "TRANSACTION_ALLOWED"
-
-	if riskScore >= 30 && riskScore <= 70 {
-		status = "FLAGGED"
-		event = "TRANSACTION_FLAGGED"
-	} else if riskScore > 70 {
-		status = "BLOCKED"
-		event = "TRANSACTION_BLOCKED"
-	}
-
-	// =================================================
-	// Notifications
-	// =================================================
-	if status == "FLAGGED" {
-		notifications.CreateTransactionNotification(
-			txn.UserID,
-			txn.ID,
-			"TXN_FLAGGED",
-			"Transaction Flagged",
-			"Your transaction was flagged due to unusual activity.",
-		)
+	// Chargebacks are confirmed fraud. A user or device with a
+	// meaningful share of charged-back payments is risky whatever
+	// the amount. One old chargeback among many payments is not.
+	if cb, err := transactions.UserChargebackStats(txn.UserID); err == nil &&
+		cb.Chargebacks > 0 && cb.Rate() >= ChargebackRateThreshold {
+		riskScore += 30
+		triggeredRules = append(triggeredRules, "HIGH_USER_CHARGEBACK_RATE")
 	}
 
-	if status == "BLOCKED" {
-		notifications.CreateTransactionNotification(
-			txn.UserID,
-			txn.ID,
-			"TXN_BLOCKED",
-			"Transaction Blocked",
-			"Your transaction was blocked due to high risk.",
-		)
+	if txn.DeviceID != "" {
+		if cb, err := transactions.DeviceChargebackStats(txn.DeviceID); err == nil &&
+			cb.Chargebacks > 0 && cb.Rate() >= ChargebackRateThreshold {
+			riskScore += 30
+			triggeredRules = append(triggeredRules, "HIGH_DEVICE_CHARGEBACK_RATE")
+		}
 	}
 
-	// ------------------------------------------------
-	// Update transaction
-	// ------------------------------------------------
-	database.DB.
-		Table("transactions").
-		Where("id = ?", txn.ID).
-		Updates(map[string]interface{}{
-			"status":     status,
-			"risk_score": riskScore,
-		})
-
 	// =================================================
-	// Audit log
+	// RULE 7: Merchant risk lists
 	// =================================================
-	audit.CreateLog(&audit.AuditLog{
-		ID:          uuid.NewString(),
-		EventType:   event,
-		EntityType:  "TRANSACTION",
-		EntityID:    txn.ID,
-		Description: "Triggered rules: " + strings.Join(triggeredRules, ","),
-		CreatedAt:   time.Now(),
-	})
+	// A blocked merchant is never paid; a watched one always gets a look.
+	if txn.MerchantID != "" && txn.MerchantID != merchants.UnknownMerchantID {
+		if merchant, err := merchants.FetchMerchant(txn.MerchantID); err == nil {
+			switch merchant.RiskStatus {
+			case merchants.RiskBlocked:
+				riskScore += 100
+				triggeredRules = append(triggeredRules, "MERCHANT_BLOCKED")
+			case merchants.RiskWatchlist:
+				riskScore += 30
+				triggeredRules = append(triggeredRules, "MERCHANT_WATCHLIST")
+			}
+		}
+	}
 
 	// =================================================
-	// Learn behavior ONLY on success
+	// RULE 8: New payee
 	// =================================================
-	if status == "SUCCESS" {
-
-		database.DB.Exec(`
-			INSERT INTO user_transaction_stats (user_id, total_txns, total_amount, avg_amount)
-			VALUES (?, 1, ?, ?)
-			ON CONFLICT (user_id)
-			DO UPDATE SET
-				total_txns = user_transaction_stats.total_txns + 1,
-				total_amount = user_transaction_stats.total_amount + EXCLUDED.total_amount,
-				avg_amount =
-					(user_transaction_stats.total_amount + EXCLUDED.total_amount)
-					/ (user_transaction_stats.total_txns + 1),
-				last_updated = NOW()
-		`, txn.UserID, txn.Amount, txn.Amount)
+	// Account takeovers usually pay someone the victim never paid before.
+	// Paying a new merchant is normal, paying one a lot more than usual is not.
+	if txn.MerchantID != "" && txn.MerchantID != merchants.UnknownMerchantID {
+		if first, err := merchants.IsFirstPayment(txn.UserID, txn.MerchantID, txn.ID); err == nil && first {
+			if stats.AvgAmount > 0 && txn.Amount >= stats.AvgAmount*2 {
+				riskScore += 30
+				triggeredRules = append(triggeredRules, "NEW_PAYEE_HIGH_AMOUNT")
+			} else {
+				riskScore += 10
+				triggeredRules = append(triggeredRules, "NEW_PAYEE")
+			}
+		}
 	}
 
 	// =================================================
-	// Store PRIMARY device only once
+	// RULE 9: Recent risky login
 	// =================================================
-	// We store device only if user has no device yet.
-	if status == "SUCCESS" && trustedDevice == "" {
-		database.DB.Table("devices").Create(map[string]interface{}{
-			"user_id":    txn.UserID,
-			"device_id":  txn.DeviceID,
-			"first_seen": time.Now(),
-		})
+	// For a while after a login that needed step-up, the account may
+	// be in someone else's hands even though they passed it. Paying from
+	// the device that made that login is the likeliest takeover path.
+	if login, err := auth.RiskyLoginSince(txn.UserID, time.Now().Add(-auth.LoginRiskWindow())); err == nil && login != nil {
+		if login.DeviceID == txn.DeviceID {
+			riskScore += 30
+			triggeredRules = append(triggeredRules, "RECENT_RISKY_LOGIN_DEVICE")
+		} else {
+			riskScore += 20
+			triggeredRules = append(triggeredRules, "RECENT_RISKY_LOGIN")
+		}
 	}
+
+	return riskScore, triggeredRules
 }
@@ -0,0 +1,67 @@
+package fraud
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/transactions"
+)
+
+/*
+restrictRepeatOffender restricts a user once too many of their
+transactions were blocked within the window. Only ACTIVE users are
+touched, since a suspension must not be downgraded; the change is made
+only if the user is still ACTIVE when it is written, and never to the
+last active admin. Blocks from before the last status change do not
+count, so lifting a restriction gives the user a clean slate.
+*/
+func restrictRepeatOffender(userID string) {
+	threshold := config.AppConfig.AutoRestrictBlockedThreshold
+	if threshold <= 0 {
+		return
+	}
+	window := time.Duration(config.AppConfig.AutoRestrictWindowHours) * time.Hour
+
+	user, err := auth.FindUser(userID)
+	if err != nil {
+		log.Println("❌ Failed to load user for auto-restriction:", userID, err)
+		return
+	}
+	if user.Status != auth.UserActive {
+		return
+	}
+
+	since := time.Now().Add(-window)
+	if user.StatusChangedAt != nil && user.StatusChangedAt.After(since) {
+		since = *user.StatusChangedAt
+	}
+
+	blocked, err := transactions.CountBlockedSince(userID, since)
+	if err != nil {
+		log.Println("❌ Failed to count blocked transactions:", userID, err)
+		return
+	}
+	if blocked < int64(threshold) {
+		return
+	}
+
+	reason := fmt.Sprintf("%d blocked transactions in %s", blocked, window)
+	_, err = auth.ChangeUserStatusFrom(userID, auth.UserActive, auth.UserRestricted, reason, "FRAUD_EVALUATOR")
+	if errors.Is(err, auth.ErrStatusChanged) || errors.Is(err, auth.ErrStatusUnchanged) {
+		log.Println("⚠️ Auto-restriction skipped, status changed meanwhile:", userID)
+		return
+	}
+	if errors.Is(err, auth.ErrLastAdmin) {
+		log.Println("⚠️ Auto-restriction skipped for the last active admin:", userID, reason)
+		return
+	}
+	if err != nil {
+		log.Println("❌ Auto-restriction failed:", userID, err)
+		return
+	}
+	log.Println("🚫 User auto-restricted:", userID, reason)
+}
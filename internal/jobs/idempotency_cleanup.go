@@ -0,0 +1,18 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/transactions"
+)
+
+func IdempotencyKeyCleanupJob() {
+	deleted, err := transactions.DeleteExpiredIdempotencyKeys(time.Now())
+	if err != nil {
+		log.Println("❌ Idempotency key cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 Idempotency key cleanup: %d rows deleted\n", deleted)
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/events"
+)
+
+func OutboxCleanupJob() {
+	cutoff := time.Now().AddDate(0, 0, -7)
+
+	deleted, err := events.DeleteSentOutboxMessages(cutoff)
+	if err != nil {
+		log.Println("❌ Outbox cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 Outbox cleanup: %d rows deleted\n", deleted)
+}
@@ -1,11 +1,16 @@
 package jobs
 
 import (
+	"context"
 	"log"
 
+	"fraud-detection-backend/internal/auth"
+
 	"github.com/robfig/cron/v3"
 )
 
+var scheduler *cron.Cron
+
 func StartScheduler() {
 	c := cron.New(cron.WithSeconds())
 
@@ -18,7 +23,38 @@ func StartScheduler() {
 	// Every 5 minutes
 	c.AddFunc("0 */5 * * * *", StaleTransactionJob)
 
+	// Hourly
+	c.AddFunc("0 0 * * * *", IdempotencyKeyCleanupJob)
+	c.AddFunc("0 15 * * * *", LoginThrottleCleanupJob)
+
+	// Daily at 03:00 AM
+	c.AddFunc("0 0 3 * * *", OutboxCleanupJob)
+
+	// Daily at 04:00 AM
+	c.AddFunc("0 0 4 * * *", RefreshTokenCleanupJob)
+	c.AddFunc("0 30 4 * * *", RevokedTokenCleanupJob)
+	c.AddFunc("0 45 4 * * *", UserTokenCleanupJob)
+	c.AddFunc("0 0 5 * * *", APIKeyRequestCleanupJob)
+
+	// Every 15 seconds, so revocations made on other instances apply quickly
+	c.AddFunc("*/15 * * * * *", auth.SyncRevocations)
+
 	log.Println("🕒 Cron scheduler started")
 
 	c.Start()
+	scheduler = c
+}
+
+// StopScheduler stops scheduling new runs and waits for running jobs until ctx expires.
+func StopScheduler(ctx context.Context) {
+	if scheduler == nil {
+		return
+	}
+
+	select {
+	case <-scheduler.Stop().Done():
+	case <-ctx.Done():
+	}
+
+	log.Println("🕒 Cron scheduler stopped")
 }
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"fraud-detection-backend/internal/auth"
+)
+
+func RefreshTokenCleanupJob() {
+	deleted, err := auth.DeleteExpiredRefreshTokens(time.Now())
+	if err != nil {
+		log.Println("❌ Refresh token cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 Refresh token cleanup: %d rows deleted\n", deleted)
+}
+
+func RevokedTokenCleanupJob() {
+	deleted, err := auth.DeleteExpiredRevokedTokens(time.Now())
+	if err != nil {
+		log.Println("❌ Revoked token cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 Revoked token cleanup: %d rows deleted\n", deleted)
+}
+
+func LoginThrottleCleanupJob() {
+	deleted, err := auth.DeleteStaleLoginThrottles(time.Now())
+	if err != nil {
+		log.Println("❌ Login throttle cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 Login throttle cleanup: %d rows deleted\n", deleted)
+}
+
+func UserTokenCleanupJob() {
+	deleted, err := auth.DeleteExpiredUserTokens(time.Now())
+	if err != nil {
+		log.Println("❌ User token cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 User token cleanup: %d rows deleted\n", deleted)
+}
+
+// API key requests are kept for 90 days.
+func APIKeyRequestCleanupJob() {
+	deleted, err := auth.DeleteAPIKeyRequestsBefore(time.Now().AddDate(0, 0, -90))
+	if err != nil {
+		log.Println("❌ API key request cleanup failed:", err)
+		return
+	}
+
+	log.Printf("🧹 API key request cleanup: %d rows deleted\n", deleted)
+}
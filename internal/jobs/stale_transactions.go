@@ -4,16 +4,34 @@ import (
 	"log"
 	"time"
 
-	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/transactions"
 )
 
 func StaleTransactionJob() {
 	cutoff := time.Now().Add(-10 * time.Minute)
 
-	result := database.DB.
-		Table("transactions").
-		Where("status = ? AND created_at < ?", "PENDING", cutoff).
-		Update("status", "FAILED")
+	ids, err := transactions.GetStalePendingIDs(cutoff)
+	if err != nil {
+		log.Println("❌ Stale transaction lookup failed:", err)
+		return
+	}
 
-	log.Printf("⏱️ Stale transactions marked FAILED: %d\n", result.RowsAffected)
+	// Each row goes through the state machine, so a transaction the
+	// evaluator is deciding right now is left alone.
+	failed := 0
+	for _, id := range ids {
+		if _, err := transactions.Transition(
+			id,
+			transactions.StatusFailed,
+			"STALE_TRANSACTION_JOB",
+			"Not evaluated within 10 minutes",
+			nil,
+		); err != nil {
+			log.Println("⏱️ Stale transaction skipped:", id, err)
+			continue
+		}
+		failed++
+	}
+
+	log.Printf("⏱️ Stale transactions marked FAILED: %d\n", failed)
 }
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer writes each message to its own .eml file, so tests and
+// local setups can pick up links and codes from disk.
+type fileMailer struct {
+	dir string
+}
+
+func newFileMailer(dir string) *fileMailer {
+	return &fileMailer{dir: dir}
+}
+
+func (m *fileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o700); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000000000"), uuid.NewString())
+
+	content := fmt.Sprintf(
+		"From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s",
+		config.AppConfig.MailFrom,
+		msg.To,
+		msg.Subject,
+		now.Format(time.RFC1123Z),
+		msg.Body,
+	)
+
+	return os.WriteFile(filepath.Join(m.dir, name), []byte(content), 0o600)
+}
@@ -0,0 +1,11 @@
+package mailer
+
+import "log"
+
+// logMailer prints messages instead of sending them, for local development.
+type logMailer struct{}
+
+func (logMailer) Send(msg Message) error {
+	log.Printf("📨 Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
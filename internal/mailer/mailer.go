@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"log"
+
+	"fraud-detection-backend/internal/config"
+)
+
+const (
+	MailerLog  = "log"
+	MailerFile = "file"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+/*
+Mailer delivers account emails. Only local implementations exist for
+now; an SMTP or provider-backed one plugs in here without callers
+changing.
+*/
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var mailer Mailer = logMailer{}
+
+func InitMailer() {
+	switch config.AppConfig.Mailer {
+	case MailerFile:
+		mailer = newFileMailer(config.AppConfig.MailerDir)
+	default:
+		mailer = logMailer{}
+	}
+
+	log.Println("📨 Mailer initialised:", config.AppConfig.Mailer)
+}
+
+func Send(msg Message) error {
+	return mailer.Send(msg)
+}
@@ -0,0 +1,38 @@
+package merchants
+
+import "time"
+
+/*
+UnknownMerchantID stands in for the merchant of transactions from
+clients that do not send one. Merchant rules skip it.
+*/
+const UnknownMerchantID = "UNKNOWN"
+
+// Merchant risk list statuses.
+const (
+	RiskNone      = "NONE"
+	RiskWatchlist = "WATCHLIST"
+	RiskBlocked   = "BLOCKED"
+)
+
+/*
+Merchant is the counterparty of a transaction: a shop, a biller or a
+person being paid. ID is the caller's identifier for it, so the same
+merchant keeps the same ID across clients.
+*/
+type Merchant struct {
+	ID         string `gorm:"primaryKey"`
+	Name       string
+	Category   string `gorm:"size:50;index"`
+	RiskStatus string `gorm:"size:20;not null;default:NONE"`
+	RiskReason string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// MerchantRef is how a transaction names its merchant.
+type MerchantRef struct {
+	ID       string
+	Name     string
+	Category string
+}
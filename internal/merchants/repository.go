@@ -0,0 +1,72 @@
+package merchants
+
+import (
+	"time"
+
+	"fraud-detection-backend/internal/database"
+
+	"gorm.io/gorm/clause"
+)
+
+func FindByID(id string) (*Merchant, error) {
+	var m Merchant
+	err := database.DB.First(&m, "id = ?", id).Error
+	return &m, err
+}
+
+/*
+FirstOrCreate returns the stored merchant, creating it from m if it is
+new. Two first payments racing to create it both end up reading the
+winner's row instead of one failing on the primary key.
+*/
+func FirstOrCreate(m *Merchant) error {
+	now := time.Now()
+	err := database.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Merchant{
+			ID:         m.ID,
+			Name:       m.Name,
+			Category:   m.Category,
+			RiskStatus: RiskNone,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}).Error
+	if err != nil {
+		return err
+	}
+	return database.DB.First(m, "id = ?", m.ID).Error
+}
+
+func List(riskStatus string, limit, offset int) ([]Merchant, error) {
+	var merchants []Merchant
+	q := database.DB.Order("created_at DESC").Limit(limit).Offset(offset)
+	if riskStatus != "" {
+		q = q.Where("risk_status = ?", riskStatus)
+	}
+	err := q.Find(&merchants).Error
+	return merchants, err
+}
+
+func UpdateRisk(id, status, reason string) error {
+	return database.DB.
+		Model(&Merchant{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"risk_status": status,
+			"risk_reason": reason,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// CountUserPayments counts earlier settled payments from userID to merchantID.
+func CountUserPayments(userID, merchantID, excludeTxnID string) (int64, error) {
+	var count int64
+	err := database.DB.
+		Table("transactions").
+		Where(
+			"user_id = ? AND merchant_id = ? AND id <> ? AND status = ?",
+			userID, merchantID, excludeTxnID, "SUCCESS",
+		).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,76 @@
+package merchants
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnknownRiskStatus = errors.New("risk_status must be NONE, WATCHLIST or BLOCKED")
+
+/*
+ResolveMerchant returns the merchant a transaction pays.
+
+Merchants are registered the first time they are paid; name and category
+from later payments never overwrite what is stored.
+*/
+func ResolveMerchant(ref MerchantRef) (*Merchant, error) {
+	if strings.TrimSpace(ref.ID) == "" {
+		ref = MerchantRef{ID: UnknownMerchantID, Name: "Unknown merchant"}
+	}
+
+	m := &Merchant{
+		ID:       ref.ID,
+		Name:     ref.Name,
+		Category: strings.ToUpper(ref.Category),
+	}
+	if err := FirstOrCreate(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func FetchMerchant(id string) (*Merchant, error) {
+	return FindByID(id)
+}
+
+func FetchMerchants(riskStatus string, limit, offset int) ([]Merchant, error) {
+	return List(strings.ToUpper(riskStatus), limit, offset)
+}
+
+// SetRiskStatus puts a merchant on (or takes it off) a risk list.
+func SetRiskStatus(id, status, reason, actor string) (*Merchant, error) {
+	status = strings.ToUpper(status)
+	if status != RiskNone && status != RiskWatchlist && status != RiskBlocked {
+		return nil, ErrUnknownRiskStatus
+	}
+
+	if _, err := FindByID(id); err != nil {
+		return nil, err
+	}
+
+	if err := UpdateRisk(id, status, reason); err != nil {
+		return nil, err
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "MERCHANT_RISK_" + status,
+		EntityType:  "MERCHANT",
+		EntityID:    id,
+		Description: "Set by " + actor + ": " + reason,
+		CreatedAt:   time.Now(),
+	})
+
+	return FindByID(id)
+}
+
+// IsFirstPayment reports whether userID has never successfully paid merchantID before.
+func IsFirstPayment(userID, merchantID, txnID string) (bool, error) {
+	count, err := CountUserPayments(userID, merchantID, txnID)
+	return count == 0, err
+}
@@ -1,35 +1,74 @@
 package middleware
 
 import (
-	"fraud-detection-backend/internal/config"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/auth"
 	"fraud-detection-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("access_token")
+		// Browsers send the cookie; other clients may send the same JWT as a bearer token.
+		tokenStr := bearerToken(c)
+		if strings.HasPrefix(tokenStr, auth.APIKeyPrefix) {
+			response.Error(c, 401, "Unauthorized", "API keys are only accepted on /service routes")
+			c.Abort()
+			return
+		}
+		if tokenStr == "" {
+			cookie, err := c.Cookie("access_token")
+			if err != nil {
+				response.Error(c, 401, "Unauthorized", "Missing auth cookie or bearer token")
+				c.Abort()
+				return
+			}
+			tokenStr = cookie
+		}
+
+		claims, err := auth.ParseToken(tokenStr)
 		if err != nil {
-			response.Error(c, 401, "Unauthorized", "Missing auth cookie")
+			response.Error(c, 401, "Unauthorized", "Invalid token")
 			c.Abort()
 			return
 		}
 
-		token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
+		userID, _ := claims["user_id"].(string)
+		sessionID, _ := claims["sid"].(string)
+		jti, _ := claims["jti"].(string)
 
-		if err != nil || !token.Valid {
-			response.Error(c, 401, "Unauthorized", "Invalid token")
+		var issuedAt, expiresAt time.Time
+		if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+			issuedAt = iat.Time
+		}
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			expiresAt = exp.Time
+		}
+
+		if auth.IsRevoked(jti, sessionID, userID, issuedAt) {
+			response.Error(c, 401, "Unauthorized", "Token revoked")
+			c.Abort()
+			return
+		}
+
+		// Suspension revokes tokens too; this covers the gap until other instances sync.
+		status := auth.UserStatus(userID)
+		if status == auth.UserSuspended || status == auth.UserDeleted {
+			response.Error(c, 403, "Forbidden", "Account suspended")
 			c.Abort()
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		c.Set("user_id", claims["user_id"])
+		c.Set("user_id", userID)
+		c.Set("user_status", status)
 		c.Set("role", claims["role"])
+		c.Set("session_id", sessionID)
+		c.Set("mfa", claims["mfa"] == true)
+		c.Set("jti", jti)
+		c.Set("token_expires_at", expiresAt)
 
 		c.Next()
 	}
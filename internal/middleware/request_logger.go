@@ -19,6 +19,7 @@ func RequestLogger() gin.HandlerFunc {
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
 			zap.Duration("latency", time.Since(start)),
+			zap.String("request_id", c.GetString("request_id")),
 		)
 	}
 }
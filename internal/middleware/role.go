@@ -1,14 +1,20 @@
 package middleware
 
 import (
+	"fraud-detection-backend/internal/auth"
 	"fraud-detection-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
 )
 
+/*
+RequireRole lets through callers whose role grants everything role
+can do, see auth.RoleGrants; routes that need one specific ability
+should use RequirePermission.
+*/
 func RequireRole(role string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if c.GetString("role") != role {
+		if !auth.RoleGrants(c.GetString("role"), role) {
 			response.Error(c, 403, "Forbidden", "Insufficient role")
 			c.Abort()
 			return
@@ -16,3 +22,27 @@ func RequireRole(role string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequirePermission checks the caller's role against auth.RolePermissions.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasPermission(c.GetString("role"), permission) {
+			response.Error(c, 403, "Forbidden", "Missing permission "+permission)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireMFA only lets through tokens whose session passed a second factor.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("mfa") {
+			response.Error(c, 403, "Forbidden", "MFA required")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
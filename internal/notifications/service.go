@@ -50,3 +50,24 @@ func ReadNotification(notificationID, userID string) error {
 func ReadAllNotifications(userID string) error {
 	return MarkAllAsRead(userID)
 }
+
+// CreateAccountNotification is for events about the account itself rather than a transaction.
+func CreateAccountNotification(userID, notificationType, title, message string) error {
+	n := &Notification{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Type:      notificationType,
+		Channel:   "IN_APP",
+		Status:    "SENT",
+		Title:     title,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+
+	if err := Create(n); err != nil {
+		log.Println("❌ Notification creation failed:", err)
+		return err
+	}
+
+	return nil
+}
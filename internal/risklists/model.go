@@ -0,0 +1,53 @@
+package risklists
+
+import "time"
+
+// Lists.
+const (
+	Allow = "ALLOW"
+	Deny  = "DENY"
+)
+
+// What an entry matches on.
+const (
+	TypeUser    = "USER"
+	TypeDevice  = "DEVICE"
+	TypeIP      = "IP"
+	TypePayment = "PAYMENT"
+)
+
+/*
+RiskListEntry puts one user, device, IP range or payment identifier on
+the allow or deny list. A value can only be on one list at a time. IP
+values are stored as CIDRs, a single address as a /32 or /128.
+Entries stop matching at ExpiresAt; nil means never.
+*/
+type RiskListEntry struct {
+	ID        string     `gorm:"type:uuid;primaryKey" json:"id"`
+	List      string     `gorm:"size:10;index;not null" json:"list"`
+	Type      string     `gorm:"size:10;not null;uniqueIndex:idx_risk_list_type_value" json:"type"`
+	Value     string     `gorm:"not null;uniqueIndex:idx_risk_list_type_value" json:"value"`
+	Reason    string     `gorm:"not null" json:"reason"`
+	CreatedBy string     `json:"created_by"`
+	UpdatedBy string     `json:"updated_by"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// EntryInput is what an admin sends to add or change an entry.
+type EntryInput struct {
+	List      string     `json:"list" binding:"required"`
+	Type      string     `json:"type"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Subject is what a transaction is checked against.
+type Subject struct {
+	UserID            string
+	DeviceID          string
+	IP                string
+	PaymentIdentifier string
+}
@@ -0,0 +1,111 @@
+package risklists
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+)
+
+func Create(e *RiskListEntry) error {
+	return database.DB.Create(e).Error
+}
+
+func FindByID(id string) (*RiskListEntry, error) {
+	var e RiskListEntry
+	err := database.DB.First(&e, "id = ?", id).Error
+	return &e, err
+}
+
+func FindByValue(entryType, value string) (*RiskListEntry, error) {
+	var e RiskListEntry
+	err := database.DB.First(&e, "type = ? AND value = ?", entryType, value).Error
+	return &e, err
+}
+
+/*
+ReviveExpired reuses the row of an expired entry for e, since a type and
+value can only have one row. It reports false if the entry was renewed
+in the meantime.
+*/
+func ReviveExpired(e *RiskListEntry, now time.Time) (bool, error) {
+	res := database.DB.Model(&RiskListEntry{}).
+		Where("id = ? AND expires_at <= ?", e.ID, now).
+		Updates(map[string]interface{}{
+			"list":       e.List,
+			"reason":     e.Reason,
+			"created_by": e.CreatedBy,
+			"updated_by": e.UpdatedBy,
+			"expires_at": e.ExpiresAt,
+			"created_at": e.CreatedAt,
+			"updated_at": e.UpdatedAt,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func Save(e *RiskListEntry) error {
+	return database.DB.Save(e).Error
+}
+
+func Delete(id string) error {
+	return database.DB.Delete(&RiskListEntry{}, "id = ?", id).Error
+}
+
+func List(list, entryType string, includeExpired bool, limit, offset int) ([]RiskListEntry, error) {
+	var entries []RiskListEntry
+	q := database.DB.Order("created_at DESC").Limit(limit).Offset(offset)
+	if list != "" {
+		q = q.Where("list = ?", list)
+	}
+	if entryType != "" {
+		q = q.Where("type = ?", entryType)
+	}
+	if !includeExpired {
+		q = q.Where("(expires_at IS NULL OR expires_at > ?)", time.Now())
+	}
+	err := q.Find(&entries).Error
+	return entries, err
+}
+
+/*
+FindMatch returns the live entry matching any part of s, deny entries
+first. IPs are matched by CIDR containment in Postgres; the CASE keeps
+the cidr cast away from user, device and payment values, which the
+planner is otherwise free to evaluate it on.
+*/
+func FindMatch(s Subject, now time.Time) (*RiskListEntry, error) {
+	var conds []string
+	var args []interface{}
+
+	for _, c := range []struct{ t, v string }{
+		{TypeUser, s.UserID},
+		{TypeDevice, s.DeviceID},
+		{TypePayment, s.PaymentIdentifier},
+	} {
+		if c.v != "" {
+			conds = append(conds, "(type = ? AND value = ?)")
+			args = append(args, c.t, c.v)
+		}
+	}
+	// Only a valid address reaches the inet cast, which would fail on anything else.
+	if ip := net.ParseIP(s.IP); ip != nil {
+		conds = append(conds, "(CASE WHEN type = ? THEN CAST(? AS inet) <<= CAST(value AS cidr) ELSE false END)")
+		args = append(args, TypeIP, ip.String())
+	}
+	if len(conds) == 0 {
+		return nil, nil
+	}
+
+	var entries []RiskListEntry
+	err := database.DB.
+		Where("("+strings.Join(conds, " OR ")+")", args...).
+		Where("(expires_at IS NULL OR expires_at > ?)", now).
+		Order("CASE WHEN list = 'DENY' THEN 0 ELSE 1 END").
+		Limit(1).
+		Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
@@ -0,0 +1,193 @@
+package risklists
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownList   = errors.New("list must be ALLOW or DENY")
+	ErrUnknownType   = errors.New("type must be USER, DEVICE, IP or PAYMENT")
+	ErrInvalidValue  = errors.New("value is missing or not valid for its type")
+	ErrInvalidExpiry = errors.New("expires_at must be in the future")
+	ErrEntryExists   = errors.New("value is already on a list")
+)
+
+// normalizeValue trims the value and turns IPs into canonical CIDRs.
+func normalizeValue(entryType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", ErrInvalidValue
+	}
+
+	if entryType != TypeIP {
+		return value, nil
+	}
+	if ip := net.ParseIP(value); ip != nil {
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String(), nil
+	}
+	_, ipNet, err := net.ParseCIDR(value)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+	return ipNet.String(), nil
+}
+
+func expired(e *RiskListEntry, now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+func validateListAndExpiry(in *EntryInput) error {
+	in.List = strings.ToUpper(strings.TrimSpace(in.List))
+	if in.List != Allow && in.List != Deny {
+		return ErrUnknownList
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return ErrInvalidExpiry
+	}
+	return nil
+}
+
+func AddEntry(in EntryInput, actor string) (*RiskListEntry, error) {
+	if err := validateListAndExpiry(&in); err != nil {
+		return nil, err
+	}
+	entryType := strings.ToUpper(strings.TrimSpace(in.Type))
+	switch entryType {
+	case TypeUser, TypeDevice, TypeIP, TypePayment:
+	default:
+		return nil, ErrUnknownType
+	}
+	value, err := normalizeValue(entryType, in.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	existing, err := FindByValue(entryType, value)
+	found := err == nil
+	if found && !expired(existing, now) {
+		return nil, ErrEntryExists
+	}
+	if !found && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	entry := &RiskListEntry{
+		ID:        uuid.NewString(),
+		List:      in.List,
+		Type:      entryType,
+		Value:     value,
+		Reason:    in.Reason,
+		CreatedBy: actor,
+		UpdatedBy: actor,
+		ExpiresAt: in.ExpiresAt,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if found {
+		// An expired entry no longer counts, but still holds the row.
+		entry.ID = existing.ID
+		revived, err := ReviveExpired(entry, now)
+		if err != nil {
+			return nil, err
+		}
+		if !revived {
+			return nil, ErrEntryExists
+		}
+	} else {
+		err := Create(entry)
+		if database.IsUniqueViolation(err) {
+			return nil, ErrEntryExists
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logEntryChange("RISK_LIST_ENTRY_ADDED", entry, actor, in.Reason)
+	return entry, nil
+}
+
+/*
+UpdateEntry moves an entry between lists or changes its reason and
+expiry. Type and value are fixed; remove the entry and add a new one
+to change what it matches.
+*/
+func UpdateEntry(id string, in EntryInput, actor string) (*RiskListEntry, error) {
+	if err := validateListAndExpiry(&in); err != nil {
+		return nil, err
+	}
+
+	entry, err := FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	from := entry.List
+
+	entry.List = in.List
+	entry.Reason = in.Reason
+	entry.ExpiresAt = in.ExpiresAt
+	entry.UpdatedBy = actor
+	entry.UpdatedAt = time.Now()
+	if err := Save(entry); err != nil {
+		return nil, err
+	}
+
+	logEntryChange("RISK_LIST_ENTRY_UPDATED", entry, actor, "was "+from+": "+in.Reason)
+	return entry, nil
+}
+
+func RemoveEntry(id, reason, actor string) error {
+	entry, err := FindByID(id)
+	if err != nil {
+		return err
+	}
+	if err := Delete(id); err != nil {
+		return err
+	}
+
+	logEntryChange("RISK_LIST_ENTRY_REMOVED", entry, actor, reason)
+	return nil
+}
+
+func FetchEntries(list, entryType string, includeExpired bool, limit, offset int) ([]RiskListEntry, error) {
+	return List(strings.ToUpper(list), strings.ToUpper(entryType), includeExpired, limit, offset)
+}
+
+func FetchEntry(id string) (*RiskListEntry, error) {
+	return FindByID(id)
+}
+
+// Match returns the entry that decides the transaction, or nil to let the rules decide.
+func Match(s Subject) (*RiskListEntry, error) {
+	return FindMatch(s, time.Now())
+}
+
+func logEntryChange(eventType string, e *RiskListEntry, actor, reason string) {
+	description := e.List + " " + e.Type + " " + e.Value + " by " + actor + ": " + reason
+	if e.ExpiresAt != nil {
+		description += " (expires " + e.ExpiresAt.Format(time.RFC3339) + ")"
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   eventType,
+		EntityType:  "RISK_LIST_ENTRY",
+		EntityID:    e.ID,
+		Description: description,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,57 @@
+package risklists
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeValue(t *testing.T) {
+	tests := []struct {
+		entryType string
+		value     string
+		want      string
+		err       error
+	}{
+		{TypeIP, "1.2.3.4", "1.2.3.4/32", nil},
+		{TypeIP, " 1.2.3.4 ", "1.2.3.4/32", nil},
+		{TypeIP, "::ffff:1.2.3.4", "1.2.3.4/32", nil},
+		{TypeIP, "10.1.2.3/8", "10.0.0.0/8", nil},
+		{TypeIP, "::1", "::1/128", nil},
+		{TypeIP, "2001:db8::1/32", "2001:db8::/32", nil},
+		{TypeIP, "bad", "", ErrInvalidValue},
+		{TypeIP, "10.0.0.0/33", "", ErrInvalidValue},
+		{TypeUser, " u1 ", "u1", nil},
+		{TypeDevice, "   ", "", ErrInvalidValue},
+		{TypePayment, "", "", ErrInvalidValue},
+	}
+
+	for _, tt := range tests {
+		got, err := normalizeValue(tt.entryType, tt.value)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("normalizeValue(%s, %q) = %q, %v; want %q, %v", tt.entryType, tt.value, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		want      bool
+	}{
+		{"never", nil, false},
+		{"past", &past, true},
+		{"now", &now, true},
+		{"future", &future, false},
+	}
+
+	for _, tt := range tests {
+		if got := expired(&RiskListEntry{ExpiresAt: tt.expiresAt}, now); got != tt.want {
+			t.Errorf("%s: expired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,18 +1,28 @@
 package router
 
 import (
+	"log"
+
 	"github.com/gin-gonic/gin"
 
 	"fraud-detection-backend/internal/admin"
 	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/config"
 	"fraud-detection-backend/internal/middleware"
 	"fraud-detection-backend/internal/notifications"
 	"fraud-detection-backend/internal/transactions"
+	"fraud-detection-backend/internal/users"
 	"fraud-detection-backend/pkg/response"
 )
 
 func SetupRouter() *gin.Engine {
 	r := gin.New()
+	// ClientIP feeds login throttling, risk scoring, IP deny lists and
+	// audit logs, so X-Forwarded-For only counts from known proxies.
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES: ", err)
+	}
+	r.Use(middleware.RequestID())
 	r.Use(middleware.RequestLogger())
 	r.Use(gin.Recovery())
 
@@ -22,9 +32,23 @@ func SetupRouter() *gin.Engine {
 	})
 
 	// -------- Auth Routes --------
+	r.GET("/.well-known/jwks.json", auth.JWKSHandler)
 	r.POST("/auth/register", auth.RegisterHandler)
-	r.POST("/auth/login", auth.LoginHandler)
+	r.POST("/auth/login", middleware.DeviceMiddleware(), auth.LoginHandler)
+	r.POST("/auth/login/step-up", middleware.DeviceMiddleware(), auth.StepUpHandler)
+	r.POST("/auth/refresh", auth.RefreshHandler)
 	r.POST("/auth/logout", middleware.AuthMiddleware(), auth.LogoutHandler)
+	r.GET("/auth/sessions", middleware.AuthMiddleware(), auth.GetSessionsHandler)
+	r.DELETE("/auth/sessions/:id", middleware.AuthMiddleware(), auth.RevokeSessionHandler)
+	r.GET("/auth/login-attempts", middleware.AuthMiddleware(), auth.GetLoginAttemptsHandler)
+	r.POST("/auth/mfa/enroll", middleware.AuthMiddleware(), auth.EnrollMFAHandler)
+	r.POST("/auth/mfa/confirm", middleware.AuthMiddleware(), auth.ConfirmMFAHandler)
+	r.POST("/auth/mfa/recovery-codes", middleware.AuthMiddleware(), auth.RegenerateRecoveryCodesHandler)
+	r.DELETE("/auth/mfa", middleware.AuthMiddleware(), auth.DisableMFAHandler)
+	r.POST("/auth/verify-email", auth.VerifyEmailHandler)
+	r.POST("/auth/verify-email/resend", middleware.AuthMiddleware(), auth.ResendVerificationHandler)
+	r.POST("/auth/password/forgot", auth.ForgotPasswordHandler)
+	r.POST("/auth/password/reset", auth.ResetPasswordHandler)
 
 	// -------- Protected User Routes --------
 	protected := r.Group("/")
@@ -35,31 +59,96 @@ func SetupRouter() *gin.Engine {
 	{
 		protected.GET("/protected/me", func(c *gin.Context) {
 			response.Success(c, "Authenticated user", gin.H{
-				"user_id":   c.GetString("user_id"),
-				"role":      c.GetString("role"),
-				"device_id": c.GetString("device_id"),
+				"user_id":     c.GetString("user_id"),
+				"role":        c.GetString("role"),
+				"permissions": auth.RolePermissions[c.GetString("role")],
+				"device_id":   c.GetString("device_id"),
 			})
 		})
 
+		protected.GET("/users/me", users.GetProfileHandler)
+		protected.PATCH("/users/me", users.UpdateProfileHandler)
+		protected.PUT("/users/me/password", users.ChangePasswordHandler)
+		protected.PUT("/users/me/email", users.ChangeEmailHandler)
+		protected.DELETE("/users/me", users.DeleteAccountHandler)
+
 		protected.POST("/transactions", transactions.CreateTransactionHandler)
+		protected.GET("/transactions", transactions.SearchTransactionsHandler)
 		protected.GET("/transactions/history", transactions.GetTransactionHistoryHandler)
+		protected.GET("/transactions/:id", transactions.GetTransactionHandler)
 		protected.GET("/notifications", notifications.GetNotificationsHandler)
 		protected.GET("/notifications/unread-count", notifications.GetUnreadCountHandler)
 		protected.PATCH("/notifications/:id/read", notifications.MarkNotificationReadHandler)
 		protected.PATCH("/notifications/read-all", notifications.MarkAllReadHandler)
 	}
 
+	// -------- Service Routes (API keys) --------
+	serviceGroup := r.Group("/service/v1")
+	serviceGroup.Use(middleware.ServiceAuthMiddleware())
+	{
+		serviceGroup.POST("/transactions", middleware.RequireScope(auth.ScopeTransactionsWrite), transactions.CreateServiceTransactionHandler)
+	}
+
 	// -------- Admin Routes (🔥 MUST BE BEFORE return) --------
+	// Each route names the permission it needs; see auth.RolePermissions.
+	// Staff sessions must have passed MFA.
 	adminGroup := r.Group("/admin")
 	adminGroup.Use(
 		middleware.AuthMiddleware(),
 		middleware.DeviceMiddleware(),
-		middleware.RequireRole("ADMIN"),
+		middleware.RequireMFA(),
 	)
 	{
-		adminGroup.GET("/transactions", admin.GetFlaggedTransactionsHandler)
-		adminGroup.GET("/fraud-evaluations", admin.GetFraudEvaluationsHandler)
-		adminGroup.GET("/audit-logs", admin.GetAuditLogsHandler)
+		read := middleware.RequirePermission(auth.PermTransactionsRead)
+		review := middleware.RequirePermission(auth.PermCasesReview)
+		auditRead := middleware.RequirePermission(auth.PermAuditRead)
+		rules := middleware.RequirePermission(auth.PermRulesWrite)
+		usersManage := middleware.RequirePermission(auth.PermUsersManage)
+		integrations := middleware.RequirePermission(auth.PermIntegrationsManage)
+
+		adminGroup.GET("/transactions", read, admin.GetFlaggedTransactionsHandler)
+		adminGroup.GET("/transactions/search", read, admin.SearchTransactionsHandler)
+		adminGroup.GET("/transactions/:id/transitions", read, admin.GetTransactionTransitionsHandler)
+		adminGroup.GET("/transactions/:id/reversals", read, admin.GetReversalsHandler)
+		adminGroup.POST("/transactions/:id/reversals", review, admin.CreateReversalHandler)
+		adminGroup.POST("/chargebacks/import", review, admin.ImportChargebacksHandler)
+		adminGroup.GET("/merchants", read, admin.GetMerchantsHandler)
+		adminGroup.GET("/merchants/stats", read, admin.GetMerchantLeaderboardHandler)
+		adminGroup.GET("/merchants/:id", read, admin.GetMerchantHandler)
+		adminGroup.PUT("/merchants/:id/risk", rules, admin.SetMerchantRiskHandler)
+		adminGroup.GET("/risk-lists", read, admin.GetRiskListEntriesHandler)
+		adminGroup.GET("/risk-lists/:id", read, admin.GetRiskListEntryHandler)
+		adminGroup.POST("/risk-lists", rules, admin.CreateRiskListEntryHandler)
+		adminGroup.PUT("/risk-lists/:id", rules, admin.UpdateRiskListEntryHandler)
+		adminGroup.DELETE("/risk-lists/:id", rules, admin.DeleteRiskListEntryHandler)
+		adminGroup.GET("/dead-letters", review, admin.GetDeadLettersHandler)
+		adminGroup.POST("/dead-letters/:id/replay", review, admin.ReplayDeadLetterHandler)
+		adminGroup.GET("/users", usersManage, admin.GetUsersHandler)
+		adminGroup.GET("/users/:id", usersManage, admin.GetUserHandler)
+		adminGroup.POST("/users/:id/suspend", usersManage, admin.SuspendUserHandler)
+		adminGroup.POST("/users/:id/restrict", usersManage, admin.RestrictUserHandler)
+		adminGroup.POST("/users/:id/reactivate", usersManage, admin.ReactivateUserHandler)
+		adminGroup.GET("/users/:id/status-history", usersManage, admin.GetUserStatusHistoryHandler)
+		adminGroup.GET("/roles", usersManage, admin.GetRolesHandler)
+		adminGroup.PUT("/users/:id/role", usersManage, admin.AssignRoleHandler)
+		adminGroup.POST("/users/:id/force-logout", usersManage, admin.ForceLogoutHandler)
+		adminGroup.GET("/users/:id/login-attempts", auditRead, admin.GetUserLoginAttemptsHandler)
+		adminGroup.POST("/users/:id/unlock", usersManage, admin.UnlockUserHandler)
+		adminGroup.POST("/service-accounts", integrations, admin.CreateServiceAccountHandler)
+		adminGroup.GET("/service-accounts", integrations, admin.GetServiceAccountsHandler)
+		adminGroup.POST("/service-accounts/:id/keys", integrations, admin.IssueAPIKeyHandler)
+		adminGroup.GET("/service-accounts/:id/keys", integrations, admin.GetAPIKeysHandler)
+		adminGroup.GET("/service-accounts/:id/requests", auditRead, admin.GetServiceAccountRequestsHandler)
+		adminGroup.DELETE("/api-keys/:id", integrations, admin.RevokeAPIKeyHandler)
+		adminGroup.POST("/webhooks", integrations, admin.CreateWebhookHandler)
+		adminGroup.GET("/webhooks", integrations, admin.GetWebhooksHandler)
+		adminGroup.PATCH("/webhooks/:id", integrations, admin.UpdateWebhookHandler)
+		adminGroup.DELETE("/webhooks/:id", integrations, admin.DeleteWebhookHandler)
+		adminGroup.GET("/webhooks/:id/deliveries", integrations, admin.GetWebhookDeliveriesHandler)
+		adminGroup.GET("/webhook-deliveries/:id", integrations, admin.GetWebhookDeliveryHandler)
+		adminGroup.POST("/webhook-deliveries/:id/redeliver", integrations, admin.RedeliverWebhookHandler)
+		adminGroup.GET("/fraud-evaluations", read, admin.GetFraudEvaluationsHandler)
+		adminGroup.GET("/audit-logs", auditRead, admin.GetAuditLogsHandler)
 	}
 
 	return r
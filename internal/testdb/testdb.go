@@ -0,0 +1,38 @@
+/*
+Package testdb points database.DB at the Postgres in TEST_DB_DSN for
+tests that need real row locks and conditional updates. Those tests
+are skipped when the variable is unset.
+*/
+package testdb
+
+import (
+	"os"
+	"testing"
+
+	"fraud-detection-backend/internal/database"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func Connect(t testing.TB, models ...interface{}) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	database.DB = db
+}
@@ -0,0 +1,47 @@
+package transactions
+
+import "time"
+
+/*
+Decision is the user-facing view of a fraud decision.
+Reason is deliberately generic: rule names and scores would tell
+a fraudster exactly which check to work around.
+*/
+type Decision struct {
+	Status      string     `json:"status"`
+	Reason      string     `json:"reason"`
+	EvaluatedAt *time.Time `json:"evaluated_at,omitempty"`
+}
+
+type TransactionDetail struct {
+	ID            string    `json:"id"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	Status        string    `json:"status"`
+	DeviceID      string    `json:"device_id"`
+	Location      string    `json:"location"`
+	PaymentMethod string    `json:"payment_method"`
+	MerchantID    string    `json:"merchant_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Decision      Decision  `json:"decision"`
+}
+
+var userSafeReasons = map[string]string{
+	StatusPending: "Your transaction is being checked.",
+	StatusSuccess: "Your transaction was approved.",
+	StatusFlagged: "Your transaction is under review due to unusual activity.",
+	StatusBlocked: "Your transaction was blocked for your protection.",
+	StatusFailed:  "Your transaction could not be processed. Please try again.",
+
+	StatusRefunded:   "Your transaction was refunded.",
+	StatusReversed:   "Your transaction was reversed.",
+	StatusChargeback: "Your transaction was disputed and charged back.",
+}
+
+// UserSafeReason explains a status without leaking fraud rule details.
+func UserSafeReason(status string) string {
+	if reason, ok := userSafeReasons[status]; ok {
+		return reason
+	}
+	return "Your transaction is being processed."
+}
@@ -1,19 +1,37 @@
 package transactions
 
 import (
-	"fraud-detection-backend/pkg/response"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"time"
+
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/merchants"
+	"fraud-detection-backend/pkg/response"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+type createTransactionRequest struct {
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+	Currency      string  `json:"currency" binding:"required"`
+	Location      string  `json:"location" binding:"required"`
+	PaymentMethod string  `json:"payment_method" binding:"required"`
+
+	PaymentIdentifier string `json:"payment_identifier"`
+
+	// Optional for clients that predate merchants; see merchants.UnknownMerchantID.
+	MerchantID       string `json:"merchant_id"`
+	MerchantName     string `json:"merchant_name"`
+	MerchantCategory string `json:"merchant_category"`
+}
+
 func CreateTransactionHandler(c *gin.Context) {
-	var req struct {
-		Amount        float64 `json:"amount" binding:"required"`
-		Currency      string  `json:"currency" binding:"required"`
-		Location      string  `json:"location" binding:"required"`
-		PaymentMethod string  `json:"payment_method" binding:"required"`
-	}
+	var req createTransactionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.Error(c, 400, "Invalid request", err.Error())
@@ -31,6 +49,41 @@ func CreateTransactionHandler(c *gin.Context) {
 		return
 	}
 
+	createTransaction(c, userID, userID, deviceID, c.ClientIP(), "", req, req)
+}
+
+/*
+createTransaction runs the shared part of the user and service
+endpoints. Idempotency keys are scoped to idempotencyOwner, the caller
+who picked them, and fingerprinted by payload.
+*/
+func createTransaction(c *gin.Context, idempotencyOwner, userID, deviceID, ip, serviceAccountID string, req createTransactionRequest, payload interface{}) *Transaction {
+	// 🔁 Retries carrying the same Idempotency-Key get the original result
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey != "" {
+		requestHash, err := HashRequest(payload)
+		if err != nil {
+			response.Error(c, 500, "Transaction failed", err.Error())
+			return nil
+		}
+
+		replay, err := BeginIdempotentRequest(idempotencyOwner, idempotencyKey, requestHash)
+		if errors.Is(err, ErrIdempotencyKeyReused) || errors.Is(err, ErrIdempotencyKeyInProgress) {
+			response.Error(c, 409, "Idempotency conflict", err.Error())
+			return nil
+		}
+		if err != nil {
+			response.Error(c, 500, "Transaction failed", err.Error())
+			return nil
+		}
+
+		if replay != nil {
+			c.Header("Idempotent-Replayed", "true")
+			response.Success(c, "Transaction created", json.RawMessage(replay.ResponseBody))
+			return nil
+		}
+	}
+
 	txn, err := CreateTransaction(
 		userID,
 		req.Amount,
@@ -38,14 +91,37 @@ func CreateTransactionHandler(c *gin.Context) {
 		deviceID,
 		req.Location,
 		req.PaymentMethod,
+		ip,
+		req.PaymentIdentifier,
+		merchants.MerchantRef{
+			ID:       req.MerchantID,
+			Name:     req.MerchantName,
+			Category: req.MerchantCategory,
+		},
+		serviceAccountID,
+		c.GetString("request_id"),
 	)
 
 	if err != nil {
+		if idempotencyKey != "" {
+			AbandonIdempotentRequest(idempotencyOwner, idempotencyKey)
+		}
+		if errors.Is(err, auth.ErrUserRestricted) || errors.Is(err, auth.ErrAccountSuspended) {
+			response.Error(c, 403, "Transaction refused", err.Error())
+			return nil
+		}
 		response.Error(c, 500, "Transaction failed", err.Error())
-		return
+		return nil
+	}
+
+	if idempotencyKey != "" {
+		if err := CompleteIdempotentRequest(idempotencyOwner, idempotencyKey, txn); err != nil {
+			log.Println("❌ Failed to store idempotent response:", err)
+		}
 	}
 
 	response.Success(c, "Transaction created", txn)
+	return txn
 }
 
 func GetTransactionHistoryHandler(c *gin.Context) {
@@ -62,3 +138,106 @@ func GetTransactionHistoryHandler(c *gin.Context) {
 
 	response.Success(c, "Transaction history fetched", txns)
 }
+
+// GET /transactions/:id
+func GetTransactionHandler(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	detail, err := FetchTransactionDetail(userID, c.Param("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 404, "Transaction not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch transaction", err.Error())
+		return
+	}
+
+	response.Success(c, "Transaction fetched", detail)
+}
+
+// GET /transactions?status=&min_amount=&max_amount=&from=&to=&currency=&payment_method=&merchant_id=&device_id=&cursor=&limit=
+func SearchTransactionsHandler(c *gin.Context) {
+	filter, err := ParseSearchFilter(c)
+	if err != nil {
+		response.Error(c, 400, "Invalid search filter", err.Error())
+		return
+	}
+
+	// Users only ever see their own transactions.
+	filter.UserID = c.GetString("user_id")
+
+	page, err := SearchUserTransactions(filter)
+	if errors.Is(err, ErrInvalidCursor) {
+		response.Error(c, 400, "Invalid search filter", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to search transactions", err.Error())
+		return
+	}
+
+	response.Success(c, "Transactions fetched", page)
+}
+
+/*
+ParseSearchFilter reads the search query parameters shared by the user
+and admin search endpoints. Dates are RFC3339, amounts are decimals.
+*/
+func ParseSearchFilter(c *gin.Context) (SearchFilter, error) {
+	filter := SearchFilter{
+		UserID:        c.Query("user_id"),
+		Status:        c.Query("status"),
+		Currency:      c.Query("currency"),
+		PaymentMethod: c.Query("payment_method"),
+		MerchantID:    c.Query("merchant_id"),
+		DeviceID:      c.Query("device_id"),
+		Cursor:        c.Query("cursor"),
+	}
+
+	var err error
+	if filter.MinAmount, err = parseFloatQuery(c, "min_amount"); err != nil {
+		return filter, err
+	}
+	if filter.MaxAmount, err = parseFloatQuery(c, "max_amount"); err != nil {
+		return filter, err
+	}
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return filter, err
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return filter, err
+	}
+
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("limit must be an integer")
+		}
+	}
+
+	return filter, nil
+}
+
+func parseFloatQuery(c *gin.Context, key string) (*float64, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &f, nil
+}
+
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", key)
+	}
+	return &t, nil
+}
@@ -0,0 +1,109 @@
+package transactions
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+)
+
+const idempotencySaveAttempts = 3
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// HashRequest fingerprints a request payload so replays can be told apart from key reuse.
+func HashRequest(payload interface{}) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+/*
+BeginIdempotentRequest claims key for userID.
+
+It returns (nil, nil) when the caller owns the key and should process the
+request, or the stored record when the request was already completed and
+its response must be replayed. A key reused with a different payload, or
+one whose original request has not finished yet, is an error.
+
+A claim is only a lease: if its request never stored a response (the
+process died, or saving failed), the same request may take the key
+over once the lease has run out instead of waiting for the TTL.
+*/
+func BeginIdempotentRequest(userID, key, requestHash string) (*IdempotencyKey, error) {
+	now := time.Now()
+	ttl := time.Duration(config.AppConfig.IdempotencyTTLHours) * time.Hour
+	lease := time.Duration(config.AppConfig.IdempotencyLeaseSeconds) * time.Second
+
+	record := &IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+
+	claimed, err := ClaimIdempotencyKey(record)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		// Expired keys are treated as never seen.
+		claimed, err = ReclaimIdempotencyKey(record, now.Add(-lease))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	existing, err := FindIdempotencyKey(userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if existing.ResponseBody == "" {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return existing, nil
+}
+
+/*
+CompleteIdempotentRequest stores the response that later replays will
+return. Saving is retried a few times; if it still fails the claim is
+left to its lease, so a retry after that reprocesses the request.
+*/
+func CompleteIdempotentRequest(userID, key string, txn *Transaction) error {
+	body, err := json.Marshal(txn)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = SaveIdempotencyResponse(userID, key, txn.ID, string(body))
+		if err == nil || attempt == idempotencySaveAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * 100 * time.Millisecond)
+	}
+}
+
+// AbandonIdempotentRequest frees the key after a failed request so the client can retry.
+func AbandonIdempotentRequest(userID, key string) error {
+	return DeleteIdempotencyKey(userID, key)
+}
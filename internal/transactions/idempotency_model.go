@@ -0,0 +1,20 @@
+package transactions
+
+import "time"
+
+/*
+IdempotencyKey remembers the outcome of a POST /transactions call made
+with an Idempotency-Key header. Keys are scoped per user, so two users
+picking the same key never collide.
+
+ResponseBody stays empty while the original request is still running.
+*/
+type IdempotencyKey struct {
+	UserID        string `gorm:"primaryKey"`
+	Key           string `gorm:"primaryKey"`
+	RequestHash   string `gorm:"not null"`
+	TransactionID string
+	ResponseBody  string `gorm:"type:text"`
+	CreatedAt     time.Time
+	ExpiresAt     time.Time `gorm:"index"`
+}
@@ -0,0 +1,116 @@
+package transactions
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/config"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/testdb"
+
+	"github.com/google/uuid"
+)
+
+func setupIdempotency(t *testing.T, leaseSeconds int) string {
+	testdb.Connect(t, &IdempotencyKey{})
+	config.AppConfig = &config.Config{
+		IdempotencyTTLHours:     24,
+		IdempotencyLeaseSeconds: leaseSeconds,
+	}
+
+	userID := uuid.NewString()
+	t.Cleanup(func() {
+		database.DB.Where("user_id = ?", userID).Delete(&IdempotencyKey{})
+	})
+	return userID
+}
+
+func TestBeginIdempotentRequestRace(t *testing.T) {
+	userID := setupIdempotency(t, 30)
+
+	const callers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	owners, inProgress := 0, 0
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replay, err := BeginIdempotentRequest(userID, "key-1", "hash-1")
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil && replay == nil:
+				owners++
+			case errors.Is(err, ErrIdempotencyKeyInProgress):
+				inProgress++
+			default:
+				t.Errorf("unexpected result: %v, %v", replay, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if owners != 1 || inProgress != callers-1 {
+		t.Fatalf("owners = %d, in progress = %d, want 1 and %d", owners, inProgress, callers-1)
+	}
+}
+
+func TestBeginIdempotentRequestReplaysAndRejectsReuse(t *testing.T) {
+	userID := setupIdempotency(t, 30)
+
+	if replay, err := BeginIdempotentRequest(userID, "key-1", "hash-1"); err != nil || replay != nil {
+		t.Fatalf("first claim = %v, %v", replay, err)
+	}
+	if err := CompleteIdempotentRequest(userID, "key-1", &Transaction{ID: "txn-1"}); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	replay, err := BeginIdempotentRequest(userID, "key-1", "hash-1")
+	if err != nil || replay == nil || replay.TransactionID != "txn-1" {
+		t.Fatalf("replay = %+v, %v", replay, err)
+	}
+
+	if _, err := BeginIdempotentRequest(userID, "key-1", "hash-2"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("reuse err = %v, want ErrIdempotencyKeyReused", err)
+	}
+}
+
+func TestStaleClaimIsReclaimedOnlyBySameRequest(t *testing.T) {
+	userID := setupIdempotency(t, 1)
+
+	if _, err := BeginIdempotentRequest(userID, "key-1", "hash-1"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	// The claim never stores a response; age it past the lease.
+	database.DB.Model(&IdempotencyKey{}).
+		Where("user_id = ?", userID).
+		Update("created_at", time.Now().Add(-time.Minute))
+
+	if _, err := BeginIdempotentRequest(userID, "key-1", "hash-2"); !errors.Is(err, ErrIdempotencyKeyReused) {
+		t.Fatalf("different payload err = %v, want ErrIdempotencyKeyReused", err)
+	}
+	if replay, err := BeginIdempotentRequest(userID, "key-1", "hash-1"); err != nil || replay != nil {
+		t.Fatalf("reclaim = %v, %v, want ownership", replay, err)
+	}
+}
+
+func TestExpiredKeyIsReclaimed(t *testing.T) {
+	userID := setupIdempotency(t, 30)
+
+	if _, err := BeginIdempotentRequest(userID, "key-1", "hash-1"); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	CompleteIdempotentRequest(userID, "key-1", &Transaction{ID: "txn-1"})
+	database.DB.Model(&IdempotencyKey{}).
+		Where("user_id = ?", userID).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	if replay, err := BeginIdempotentRequest(userID, "key-1", "hash-2"); err != nil || replay != nil {
+		t.Fatalf("expired key = %v, %v, want ownership", replay, err)
+	}
+}
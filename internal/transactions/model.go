@@ -12,5 +12,26 @@ type Transaction struct {
 	DeviceID      string `gorm:"index"`
 	Location      string
 	PaymentMethod string
-	CreatedAt     time.Time
+
+	// IP is the customer's address. PaymentIdentifier is a token or
+	// fingerprint of the card or account paid from, never a raw number.
+	IP                string `gorm:"index"`
+	PaymentIdentifier string `gorm:"index"`
+
+	MerchantID       string `gorm:"index"`
+	MerchantCategory string
+
+	// ServiceAccountID is set when a merchant backend submitted the
+	// transaction on the user's behalf, empty when the user did.
+	ServiceAccountID string `gorm:"index;not null;default:''"`
+
+	// RefundedAmount adds up partial refunds; the status only becomes
+	// REFUNDED once it reaches Amount.
+	RefundedAmount float64 `gorm:"not null;default:0"`
+
+	FraudLabel string `gorm:"index"`
+	LabeledAt  *time.Time
+	Version    int `gorm:"not null;default:1"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
 }
@@ -1,11 +1,45 @@
 package transactions
 
-import "fraud-detection-backend/internal/database"
+import (
+	"time"
+
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/events"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 func Create(txn *Transaction) error {
 	return database.DB.Create(txn).Error
 }
 
+/*
+CreateWithEvent inserts txn, its initial PENDING history row and its
+event in the outbox atomically.
+*/
+func CreateWithEvent(txn *Transaction, correlationID string, event events.TransactionEvent) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(txn).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&TransactionTransition{
+			ID:            uuid.NewString(),
+			TransactionID: txn.ID,
+			FromStatus:    "",
+			ToStatus:      txn.Status,
+			Version:       txn.Version,
+			Actor:         "API",
+			Reason:        "Transaction created",
+			CreatedAt:     txn.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+		return events.EnqueueTransactionCreated(tx, correlationID, event)
+	})
+}
+
 func FindByID(id string) (*Transaction, error) {
 	var txn Transaction
 	err := database.DB.First(&txn, "id = ?", id).Error
@@ -22,3 +56,246 @@ func GetUserTransactions(userID string, limit, offset int) ([]Transaction, error
 		Find(&txns).Error
 	return txns, err
 }
+
+/*
+ApplyTransition moves txn to a new status guarded by its version.
+
+The update only matches the row if nobody else changed it since it was
+loaded; otherwise ErrVersionConflict is returned and nothing is written.
+The history row is written in the same DB transaction.
+*/
+func ApplyTransition(
+	txn *Transaction,
+	to, actor, reason string,
+	fields map[string]interface{},
+	hooks ...func(tx *gorm.DB) error,
+) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyTransitionTx(tx, txn, to, actor, reason, fields); err != nil {
+			return err
+		}
+		for _, hook := range hooks {
+			if err := hook(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func applyTransitionTx(tx *gorm.DB, txn *Transaction, to, actor, reason string, fields map[string]interface{}) error {
+	updates := map[string]interface{}{}
+	for k, v := range fields {
+		updates[k] = v
+	}
+	updates["status"] = to
+	updates["version"] = txn.Version + 1
+	updates["updated_at"] = time.Now()
+
+	result := tx.
+		Model(&Transaction{}).
+		Where("id = ? AND version = ?", txn.ID, txn.Version).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	return tx.Create(&TransactionTransition{
+		ID:            uuid.NewString(),
+		TransactionID: txn.ID,
+		FromStatus:    txn.Status,
+		ToStatus:      to,
+		Version:       txn.Version + 1,
+		Actor:         actor,
+		Reason:        reason,
+		CreatedAt:     time.Now(),
+	}).Error
+}
+
+func GetTransitions(txnID string) ([]TransactionTransition, error) {
+	var transitions []TransactionTransition
+	err := database.DB.
+		Where("transaction_id = ?", txnID).
+		Order("version ASC").
+		Find(&transitions).Error
+	return transitions, err
+}
+
+/*
+GetStalePendingIDs skips transactions whose created event is still in the
+outbox: those were never handed to the evaluator, so failing them would
+drop them unscored.
+*/
+func GetStalePendingIDs(cutoff time.Time) ([]string, error) {
+	var ids []string
+	err := database.DB.
+		Model(&Transaction{}).
+		Where("status = ? AND created_at < ?", StatusPending, cutoff).
+		Where(
+			"NOT EXISTS (SELECT 1 FROM outbox_messages o WHERE o.aggregate_id = transactions.id AND o.status = ?)",
+			events.OutboxPending,
+		).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+/*
+CountBlockedSince counts the blocked transactions the user submitted
+themselves. A merchant backend can name any user, so its submissions
+must not be able to get someone restricted.
+*/
+func CountBlockedSince(userID string, since time.Time) (int64, error) {
+	var count int64
+	err := database.DB.
+		Model(&Transaction{}).
+		Where("user_id = ? AND status = ? AND created_at >= ?", userID, StatusBlocked, since).
+		Where("service_account_id = ''").
+		Count(&count).Error
+	return count, err
+}
+
+func FindUserTransaction(userID, id string) (*Transaction, error) {
+	var txn Transaction
+	err := database.DB.First(&txn, "id = ? AND user_id = ?", id, userID).Error
+	return &txn, err
+}
+
+// GetLatestEvaluationTime returns when the transaction was last scored, if ever.
+func GetLatestEvaluationTime(txnID string) (*time.Time, error) {
+	var evaluatedAt []time.Time
+	err := database.DB.
+		Table("fraud_evaluations").
+		Where("transaction_id = ?", txnID).
+		Order("created_at DESC").
+		Limit(1).
+		Pluck("created_at", &evaluatedAt).Error
+	if err != nil || len(evaluatedAt) == 0 {
+		return nil, err
+	}
+	return &evaluatedAt[0], nil
+}
+
+// ClaimIdempotencyKey inserts the key and reports whether this call created it.
+func ClaimIdempotencyKey(record *IdempotencyKey) (bool, error) {
+	result := database.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(record)
+	return result.RowsAffected == 1, result.Error
+}
+
+/*
+ReclaimIdempotencyKey takes over an existing key in one conditional
+UPDATE: either it has expired, or its request never stored a response
+and its lease ran out, in which case only the same payload may take it.
+*/
+func ReclaimIdempotencyKey(record *IdempotencyKey, leaseCutoff time.Time) (bool, error) {
+	result := database.DB.
+		Model(&IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", record.UserID, record.Key).
+		Where(
+			"(expires_at < ? OR (response_body = '' AND created_at < ? AND request_hash = ?))",
+			record.CreatedAt, leaseCutoff, record.RequestHash,
+		).
+		Updates(map[string]interface{}{
+			"request_hash":   record.RequestHash,
+			"transaction_id": "",
+			"response_body":  "",
+			"created_at":     record.CreatedAt,
+			"expires_at":     record.ExpiresAt,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func FindIdempotencyKey(userID, key string) (*IdempotencyKey, error) {
+	var record IdempotencyKey
+	err := database.DB.First(&record, "user_id = ? AND key = ?", userID, key).Error
+	return &record, err
+}
+
+func SaveIdempotencyResponse(userID, key, txnID, body string) error {
+	return database.DB.
+		Model(&IdempotencyKey{}).
+		Where("user_id = ? AND key = ?", userID, key).
+		Updates(map[string]interface{}{
+			"transaction_id": txnID,
+			"response_body":  body,
+		}).Error
+}
+
+func DeleteIdempotencyKey(userID, key string) error {
+	return database.DB.
+		Where("user_id = ? AND key = ?", userID, key).
+		Delete(&IdempotencyKey{}).Error
+}
+
+func DeleteExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	result := database.DB.
+		Where("expires_at < ?", now).
+		Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
+
+// CreateReversal stores rev and transitions txn in one DB transaction.
+func CreateReversal(rev *Reversal, txn *Transaction, to, actor, reason string, fields map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rev).Error; err != nil {
+			return err
+		}
+		return applyTransitionTx(tx, txn, to, actor, reason, fields)
+	})
+}
+
+/*
+CreatePartialRefund records a refund that leaves part of the amount in
+place. The status stays, but the version still moves so concurrent
+refunds cannot both pass the remaining-amount check, and like every
+version it gets a transition row, from and to the same status.
+*/
+func CreatePartialRefund(rev *Reversal, txn *Transaction, actor, reason string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(rev).Error; err != nil {
+			return err
+		}
+		return applyTransitionTx(tx, txn, txn.Status, actor, reason, map[string]interface{}{
+			"refunded_amount": gorm.Expr("refunded_amount + ?", rev.Amount),
+		})
+	})
+}
+
+func ReversalExists(externalRef string) (bool, error) {
+	var count int64
+	err := database.DB.
+		Model(&Reversal{}).
+		Where("external_ref = ?", externalRef).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func GetReversals(txnID string) ([]Reversal, error) {
+	var reversals []Reversal
+	err := database.DB.
+		Where("transaction_id = ?", txnID).
+		Order("created_at ASC").
+		Find(&reversals).Error
+	return reversals, err
+}
+
+// GetChargebackStats counts settled and charged-back transactions where column = value.
+func GetChargebackStats(column, value string) (ChargebackStats, error) {
+	var stats ChargebackStats
+	err := database.DB.
+		Model(&Transaction{}).
+		Select(
+			"COUNT(*) FILTER (WHERE status = ?) AS chargebacks, COUNT(*) AS settled",
+			StatusChargeback,
+		).
+		Where(column+" = ?", value).
+		Where("status IN ?", []string{
+			StatusSuccess, StatusFlagged, StatusRefunded, StatusReversed, StatusChargeback,
+		}).
+		Scan(&stats).Error
+	return stats, err
+}
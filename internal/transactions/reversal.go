@@ -0,0 +1,201 @@
+package transactions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/database"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownReversalType = errors.New("reversal type must be REFUND, REVERSAL or CHARGEBACK")
+	ErrInvalidReversal     = errors.New("reversal amount must be positive and not exceed the transaction amount")
+	ErrDuplicateReversal   = errors.New("a reversal with this external reference already exists")
+)
+
+var reversalStatus = map[string]string{
+	ReversalRefund:     StatusRefunded,
+	ReversalReversal:   StatusReversed,
+	ReversalChargeback: StatusChargeback,
+}
+
+type ReversalInput struct {
+	TransactionID string  `json:"transaction_id"`
+	Type          string  `json:"type"`
+	Amount        float64 `json:"amount"`
+	ReasonCode    string  `json:"reason_code"`
+	Reason        string  `json:"reason"`
+	ExternalRef   string  `json:"external_ref"`
+}
+
+// refundEpsilon absorbs float rounding when partial refunds add up to the amount.
+const refundEpsilon = 0.005
+
+/*
+reversalAmount checks in.Amount against what is left of txn after
+earlier refunds, whatever the kind of reversal, so refunds and a later
+reversal or chargeback never add up to more than the transaction. A
+zero amount means everything that is left. partial is true for a
+refund that leaves part of the amount in place.
+*/
+func reversalAmount(txn *Transaction, in ReversalInput) (amount float64, partial bool, err error) {
+	available := txn.Amount - txn.RefundedAmount
+
+	amount = in.Amount
+	if amount == 0 {
+		amount = available
+	}
+	if amount <= 0 || amount > available+refundEpsilon {
+		return 0, false, ErrInvalidReversal
+	}
+	return amount, in.Type == ReversalRefund && amount < available-refundEpsilon, nil
+}
+
+/*
+RegisterReversal records a refund, reversal or chargeback and moves the
+transaction into the matching status in the same DB transaction.
+
+Refunds may be partial: each one is recorded against the amount not yet
+refunded, and the transaction only becomes REFUNDED with the last one.
+A chargeback also labels the original transaction as confirmed fraud.
+*/
+func RegisterReversal(in ReversalInput, source, actor string) (*Reversal, error) {
+	in.Type = strings.ToUpper(in.Type)
+	to, ok := reversalStatus[in.Type]
+	if !ok {
+		return nil, ErrUnknownReversalType
+	}
+
+	txn, err := FindByID(in.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, partial, err := reversalAmount(txn, in)
+	if err != nil {
+		return nil, err
+	}
+	in.Amount = amount
+
+	if !CanTransition(txn.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, txn.Status, to)
+	}
+
+	rev := &Reversal{
+		ID:            uuid.NewString(),
+		TransactionID: txn.ID,
+		Type:          in.Type,
+		Amount:        in.Amount,
+		Currency:      txn.Currency,
+		ReasonCode:    in.ReasonCode,
+		Reason:        in.Reason,
+		Source:        source,
+		CreatedBy:     actor,
+		CreatedAt:     time.Now(),
+	}
+	if in.ExternalRef != "" {
+		rev.ExternalRef = &in.ExternalRef
+
+		exists, err := ReversalExists(in.ExternalRef)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, ErrDuplicateReversal
+		}
+	}
+
+	fields := map[string]interface{}{}
+	if in.Type == ReversalRefund {
+		fields["refunded_amount"] = txn.RefundedAmount + in.Amount
+	}
+	if in.Type == ReversalChargeback {
+		fields["fraud_label"] = FraudLabelConfirmed
+		fields["labeled_at"] = time.Now()
+	}
+
+	reason := in.Type + " " + in.ReasonCode
+	if partial {
+		err = CreatePartialRefund(rev, txn, actor, reason+" (partial)")
+	} else {
+		err = CreateReversal(rev, txn, to, actor, reason, fields)
+	}
+	// The pre-check misses a concurrent import of the same reference.
+	if database.IsUniqueViolation(err) {
+		return nil, ErrDuplicateReversal
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "TRANSACTION_" + in.Type,
+		EntityType:  "TRANSACTION",
+		EntityID:    txn.ID,
+		Description: fmt.Sprintf("%s of %.2f %s registered by %s (%s): %s", in.Type, in.Amount, txn.Currency, actor, source, in.Reason),
+		CreatedAt:   time.Now(),
+	})
+
+	return rev, nil
+}
+
+type ImportResult struct {
+	TransactionID string `json:"transaction_id"`
+	ExternalRef   string `json:"external_ref,omitempty"`
+	ReversalID    string `json:"reversal_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// ImportReversals registers a processor batch; one bad record does not stop the rest.
+func ImportReversals(records []ReversalInput, actor string) []ImportResult {
+	results := make([]ImportResult, 0, len(records))
+
+	for _, rec := range records {
+		res := ImportResult{TransactionID: rec.TransactionID, ExternalRef: rec.ExternalRef}
+
+		rev, err := RegisterReversal(rec, ReversalSourceImport, actor)
+		if err != nil {
+			res.Error = err.Error()
+		} else {
+			res.ReversalID = rev.ID
+		}
+
+		results = append(results, res)
+	}
+
+	return results
+}
+
+func FetchReversals(txnID string) ([]Reversal, error) {
+	return GetReversals(txnID)
+}
+
+/*
+ChargebackStats is how often chargebacks hit a user or device
+compared to the transactions that actually went through.
+*/
+type ChargebackStats struct {
+	Chargebacks int64
+	Settled     int64
+}
+
+func (s ChargebackStats) Rate() float64 {
+	if s.Settled == 0 {
+		return 0
+	}
+	return float64(s.Chargebacks) / float64(s.Settled)
+}
+
+func UserChargebackStats(userID string) (ChargebackStats, error) {
+	return GetChargebackStats("user_id", userID)
+}
+
+func DeviceChargebackStats(deviceID string) (ChargebackStats, error) {
+	return GetChargebackStats("device_id", deviceID)
+}
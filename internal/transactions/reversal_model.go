@@ -0,0 +1,40 @@
+package transactions
+
+import "time"
+
+// Reversal types.
+const (
+	ReversalRefund     = "REFUND"
+	ReversalReversal   = "REVERSAL"
+	ReversalChargeback = "CHARGEBACK"
+)
+
+// Reversal sources.
+const (
+	ReversalSourceAdmin  = "ADMIN"
+	ReversalSourceImport = "IMPORT"
+)
+
+// FraudLabelConfirmed marks a transaction that a chargeback proved fraudulent.
+const FraudLabelConfirmed = "CONFIRMED_FRAUD"
+
+/*
+Reversal is money coming back on a transaction: a refund we issued,
+a reversal by the processor, or a chargeback raised by the card holder.
+Chargebacks are the ground truth for fraud labels.
+
+ExternalRef is the processor's reference; it makes imports re-runnable.
+*/
+type Reversal struct {
+	ID            string  `gorm:"primaryKey"`
+	TransactionID string  `gorm:"index;not null"`
+	Type          string  `gorm:"size:20;not null"`
+	Amount        float64 `gorm:"not null"`
+	Currency      string
+	ReasonCode    string
+	Reason        string
+	ExternalRef   *string `gorm:"uniqueIndex"`
+	Source        string  `gorm:"size:20;not null"`
+	CreatedBy     string
+	CreatedAt     time.Time
+}
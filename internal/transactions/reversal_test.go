@@ -0,0 +1,88 @@
+package transactions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/testdb"
+
+	"github.com/google/uuid"
+)
+
+func TestReversalAmount(t *testing.T) {
+	txn := &Transaction{Amount: 100, RefundedAmount: 40}
+
+	tests := []struct {
+		name    string
+		in      ReversalInput
+		amount  float64
+		partial bool
+		err     error
+	}{
+		{"partial refund", ReversalInput{Type: ReversalRefund, Amount: 10}, 10, true, nil},
+		{"rest refunded", ReversalInput{Type: ReversalRefund}, 60, false, nil},
+		{"refund over the rest", ReversalInput{Type: ReversalRefund, Amount: 61}, 0, false, ErrInvalidReversal},
+		{"full chargeback takes the rest", ReversalInput{Type: ReversalChargeback}, 60, false, nil},
+		{"chargeback over the rest", ReversalInput{Type: ReversalChargeback, Amount: 100}, 0, false, ErrInvalidReversal},
+		{"full reversal takes the rest", ReversalInput{Type: ReversalReversal}, 60, false, nil},
+		{"reversal over the rest", ReversalInput{Type: ReversalReversal, Amount: 60.01}, 0, false, ErrInvalidReversal},
+		{"negative", ReversalInput{Type: ReversalRefund, Amount: -1}, 0, false, ErrInvalidReversal},
+	}
+
+	for _, tt := range tests {
+		amount, partial, err := reversalAmount(txn, tt.in)
+		if !errors.Is(err, tt.err) || amount != tt.amount || partial != tt.partial {
+			t.Errorf("%s: got %v, %v, %v; want %v, %v, %v", tt.name, amount, partial, err, tt.amount, tt.partial, tt.err)
+		}
+	}
+}
+
+func TestPartialRefundThenChargeback(t *testing.T) {
+	testdb.Connect(t, &Transaction{}, &Reversal{}, &TransactionTransition{})
+
+	txn := &Transaction{
+		ID:        uuid.NewString(),
+		UserID:    uuid.NewString(),
+		Amount:    100,
+		Currency:  "EUR",
+		Status:    StatusSuccess,
+		Version:   1,
+		CreatedAt: time.Now(),
+	}
+	if err := database.DB.Create(txn).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.DB.Delete(&TransactionTransition{}, "transaction_id = ?", txn.ID)
+		database.DB.Delete(&Reversal{}, "transaction_id = ?", txn.ID)
+		database.DB.Delete(&Transaction{}, "id = ?", txn.ID)
+	})
+
+	if _, err := RegisterReversal(ReversalInput{TransactionID: txn.ID, Type: ReversalRefund, Amount: 40}, ReversalSourceAdmin, "admin"); err != nil {
+		t.Fatalf("partial refund: %v", err)
+	}
+
+	// The partial refund moved the version, so it needs a transition row.
+	transitions, err := GetTransitions(txn.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transitions) != 1 || transitions[0].Version != 2 || transitions[0].FromStatus != StatusSuccess || transitions[0].ToStatus != StatusSuccess {
+		t.Fatalf("transitions = %+v, want one SUCCESS -> SUCCESS at version 2", transitions)
+	}
+
+	_, err = RegisterReversal(ReversalInput{TransactionID: txn.ID, Type: ReversalChargeback, Amount: 100}, ReversalSourceAdmin, "admin")
+	if !errors.Is(err, ErrInvalidReversal) {
+		t.Fatalf("chargeback of the full amount: err = %v, want ErrInvalidReversal", err)
+	}
+
+	rev, err := RegisterReversal(ReversalInput{TransactionID: txn.ID, Type: ReversalChargeback}, ReversalSourceAdmin, "admin")
+	if err != nil {
+		t.Fatalf("chargeback of the rest: %v", err)
+	}
+	if rev.Amount != 60 {
+		t.Errorf("chargeback amount = %v, want 60", rev.Amount)
+	}
+}
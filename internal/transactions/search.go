@@ -0,0 +1,144 @@
+package transactions
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/database"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+/*
+SearchFilter narrows a transaction search. Zero values mean "no filter".
+UserID is always set by the caller for user searches and optional for admins.
+*/
+type SearchFilter struct {
+	UserID        string
+	Status        string
+	MinAmount     *float64
+	MaxAmount     *float64
+	From          *time.Time
+	To            *time.Time
+	Currency      string
+	PaymentMethod string
+	MerchantID    string
+	DeviceID      string
+	Cursor        string
+	Limit         int
+}
+
+type SearchPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
+
+// UserSearchPage hides scores and labels, like TransactionDetail.
+type UserSearchPage struct {
+	Transactions []TransactionDetail `json:"transactions"`
+	NextCursor   string              `json:"next_cursor,omitempty"`
+}
+
+/*
+Search uses keyset pagination on (created_at, id) instead of OFFSET,
+so rows inserted while a client is paging never shift or duplicate
+results on the following pages.
+*/
+func Search(f SearchFilter) (*SearchPage, error) {
+	if f.Limit <= 0 {
+		f.Limit = DefaultSearchLimit
+	}
+	if f.Limit > MaxSearchLimit {
+		f.Limit = MaxSearchLimit
+	}
+
+	q := database.DB.Model(&Transaction{})
+
+	if f.UserID != "" {
+		q = q.Where("user_id = ?", f.UserID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", strings.ToUpper(f.Status))
+	}
+	if f.MinAmount != nil {
+		q = q.Where("amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		q = q.Where("amount <= ?", *f.MaxAmount)
+	}
+	if f.From != nil {
+		q = q.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		q = q.Where("created_at < ?", *f.To)
+	}
+	if f.Currency != "" {
+		q = q.Where("currency = ?", strings.ToUpper(f.Currency))
+	}
+	if f.PaymentMethod != "" {
+		q = q.Where("payment_method = ?", f.PaymentMethod)
+	}
+	if f.MerchantID != "" {
+		q = q.Where("merchant_id = ?", f.MerchantID)
+	}
+	if f.DeviceID != "" {
+		q = q.Where("device_id = ?", f.DeviceID)
+	}
+
+	if f.Cursor != "" {
+		createdAt, id, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		q = q.Where("(created_at, id) < (?, ?)", createdAt, id)
+	}
+
+	// Fetch one extra row to know whether another page exists.
+	var txns []Transaction
+	if err := q.
+		Order("created_at DESC, id DESC").
+		Limit(f.Limit + 1).
+		Find(&txns).Error; err != nil {
+		return nil, err
+	}
+
+	page := &SearchPage{Transactions: txns}
+	if len(txns) > f.Limit {
+		page.Transactions = txns[:f.Limit]
+		last := page.Transactions[f.Limit-1]
+		page.NextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+func encodeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+
+	return createdAt, parts[1], nil
+}
@@ -0,0 +1,42 @@
+package transactions
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2026, 3, 14, 9, 26, 53, 589793000, time.FixedZone("CET", 3600))
+	id := "4b1f1b9e-6f0c-4a8a-9d7e-1c2b3d4e5f60"
+
+	gotTime, gotID, err := decodeCursor(encodeCursor(createdAt, id))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if !gotTime.Equal(createdAt) {
+		t.Errorf("created_at = %v, want %v", gotTime, createdAt)
+	}
+	if gotID != id {
+		t.Errorf("id = %q, want %q", gotID, id)
+	}
+}
+
+func TestDecodeCursorRejectsBadInput(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := map[string]string{
+		"not base64":    "%%%",
+		"no separator":  enc("2026-03-14T09:26:53Z"),
+		"empty id":      enc("2026-03-14T09:26:53Z|"),
+		"bad timestamp": enc("yesterday|abc"),
+		"empty":         "",
+	}
+
+	for name, cursor := range tests {
+		if _, _, err := decodeCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+}
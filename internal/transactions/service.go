@@ -1,11 +1,15 @@
 package transactions
 
 import (
+	"fmt"
 	"time"
 
+	"fraud-detection-backend/internal/auth"
 	"fraud-detection-backend/internal/events"
+	"fraud-detection-backend/internal/merchants"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 func CreateTransaction(
@@ -15,34 +19,167 @@ func CreateTransaction(
 	deviceID string,
 	location string,
 	paymentMethod string,
+	ip string,
+	paymentIdentifier string,
+	merchantRef merchants.MerchantRef,
+	serviceAccountID string,
+	correlationID string,
 ) (*Transaction, error) {
 
+	// Restricted and suspended users keep their history but cannot add to it.
+	if err := auth.CheckCanTransact(userID); err != nil {
+		return nil, err
+	}
+
+	merchant, err := merchants.ResolveMerchant(merchantRef)
+	if err != nil {
+		return nil, err
+	}
+
 	txn := &Transaction{
 		ID:            uuid.NewString(),
 		UserID:        userID,
 		Amount:        amount,
 		Currency:      currency,
-		Status:        "PENDING",
+		Status:        StatusPending,
 		RiskScore:     0,
 		DeviceID:      deviceID,
 		Location:      location,
 		PaymentMethod: paymentMethod,
-		CreatedAt:     time.Now(),
-	}
 
-	if err := Create(txn); err != nil {
-		return nil, err
+		IP:                ip,
+		PaymentIdentifier: paymentIdentifier,
+
+		MerchantID:       merchant.ID,
+		MerchantCategory: merchant.Category,
+
+		ServiceAccountID: serviceAccountID,
+
+		Version:   1,
+		CreatedAt: time.Now(),
 	}
 
-	events.PublishTransactionCreated(events.TransactionEvent{
+	// The transaction row and its event commit together, so a broker
+	// outage delays scoring instead of losing it.
+	if err := CreateWithEvent(txn, correlationID, events.TransactionEvent{
 		TransactionID: txn.ID,
 		UserID:        txn.UserID,
 		Amount:        txn.Amount,
-	})
+		Currency:      txn.Currency,
+		DeviceID:      txn.DeviceID,
+		MerchantID:    txn.MerchantID,
+		CreatedAt:     txn.CreatedAt,
+	}); err != nil {
+		return nil, err
+	}
 
 	return txn, nil
 }
 
-func FetchTransactionHistory(userID string, limit, offset int) ([]Transaction, error) {
-	return GetUserTransactions(userID, limit, offset)
+func FetchTransactionHistory(userID string, limit, offset int) ([]TransactionDetail, error) {
+	txns, err := GetUserTransactions(userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return toDetails(txns), nil
+}
+
+/*
+Transition is the only way a transaction's status may change.
+
+It loads the current row, rejects moves the state machine does not allow
+and applies the change with optimistic locking, so two writers racing on
+the same transaction (e.g. the evaluator and the stale job) cannot both win.
+
+Hooks run inside the same DB transaction as the update, which is how
+callers queue outbox events that must only exist if the change committed.
+*/
+func Transition(
+	txnID string,
+	to string,
+	actor string,
+	reason string,
+	fields map[string]interface{},
+	hooks ...func(tx *gorm.DB) error,
+) (*Transaction, error) {
+
+	txn, err := FindByID(txnID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !CanTransition(txn.Status, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, txn.Status, to)
+	}
+
+	if err := ApplyTransition(txn, to, actor, reason, fields, hooks...); err != nil {
+		return nil, err
+	}
+
+	return FindByID(txnID)
+}
+
+func FetchTransitions(txnID string) ([]TransactionTransition, error) {
+	return GetTransitions(txnID)
+}
+
+// FetchTransactionDetail returns a transaction only if it belongs to userID.
+func FetchTransactionDetail(userID, txnID string) (*TransactionDetail, error) {
+	txn, err := FindUserTransaction(userID, txnID)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluatedAt, err := GetLatestEvaluationTime(txn.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := toDetail(txn)
+	detail.Decision.EvaluatedAt = evaluatedAt
+	return &detail, nil
+}
+
+// toDetail maps a transaction to the user-safe view, without the evaluation time.
+func toDetail(txn *Transaction) TransactionDetail {
+	return TransactionDetail{
+		ID:            txn.ID,
+		Amount:        txn.Amount,
+		Currency:      txn.Currency,
+		Status:        txn.Status,
+		DeviceID:      txn.DeviceID,
+		Location:      txn.Location,
+		PaymentMethod: txn.PaymentMethod,
+		MerchantID:    txn.MerchantID,
+		CreatedAt:     txn.CreatedAt,
+		Decision: Decision{
+			Status: txn.Status,
+			Reason: UserSafeReason(txn.Status),
+		},
+	}
+}
+
+func toDetails(txns []Transaction) []TransactionDetail {
+	details := make([]TransactionDetail, len(txns))
+	for i := range txns {
+		details[i] = toDetail(&txns[i])
+	}
+	return details
+}
+
+// SearchTransactions is the admin search; it returns full rows.
+func SearchTransactions(filter SearchFilter) (*SearchPage, error) {
+	return Search(filter)
+}
+
+// SearchUserTransactions is the user search, mapped to the same view as the detail endpoint.
+func SearchUserTransactions(filter SearchFilter) (*UserSearchPage, error) {
+	page, err := Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &UserSearchPage{
+		Transactions: toDetails(page.Transactions),
+		NextCursor:   page.NextCursor,
+	}, nil
 }
@@ -0,0 +1,84 @@
+package transactions
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+/*
+POST /service/v1/transactions
+
+For merchant backends authenticating with an API key. They submit on
+behalf of one of our users, so the user and the customer's device come
+from the body instead of the session. The merchant is always the service
+account's own, so merchant_id may be left out.
+*/
+func CreateServiceTransactionHandler(c *gin.Context) {
+	var req struct {
+		createTransactionRequest
+
+		UserID   string `json:"user_id" binding:"required"`
+		DeviceID string `json:"device_id" binding:"required"`
+
+		// The customer's address, not the merchant backend's.
+		IP string `json:"ip"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+	if req.IP != "" && net.ParseIP(req.IP) == nil {
+		response.Error(c, 400, "Invalid request", "ip is not a valid address")
+		return
+	}
+
+	_, err := auth.FindUser(req.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		response.Error(c, 422, "Unknown user", req.UserID)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Transaction failed", err.Error())
+		return
+	}
+
+	serviceAccountID := c.GetString("service_account_id")
+
+	// A merchant backend only submits its own merchant's payments.
+	merchantID := c.GetString("service_merchant_id")
+	if merchantID == "" {
+		response.Error(c, 403, "Transaction refused", "service account is not linked to a merchant")
+		return
+	}
+	if req.MerchantID == "" {
+		req.MerchantID = merchantID
+	}
+	if req.MerchantID != merchantID {
+		response.Error(c, 403, "Transaction refused", "merchant_id does not belong to this service account")
+		return
+	}
+
+	txn := createTransaction(c, "service:"+serviceAccountID, req.UserID, req.DeviceID, req.IP, serviceAccountID, req.createTransactionRequest, req)
+	if txn == nil {
+		return
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "TRANSACTION_SUBMITTED_BY_SERVICE",
+		EntityType:  "TRANSACTION",
+		EntityID:    txn.ID,
+		Description: "Service account " + serviceAccountID + " for user " + req.UserID,
+		CreatedAt:   time.Now(),
+	})
+}
@@ -0,0 +1,48 @@
+package transactions
+
+import "errors"
+
+// Transaction lifecycle states.
+const (
+	StatusPending = "PENDING"
+	StatusSuccess = "SUCCESS"
+	StatusFlagged = "FLAGGED"
+	StatusBlocked = "BLOCKED"
+	StatusFailed  = "FAILED"
+
+	StatusRefunded   = "REFUNDED"
+	StatusReversed   = "REVERSED"
+	StatusChargeback = "CHARGEBACK"
+)
+
+var (
+	ErrIllegalTransition = errors.New("illegal transaction status transition")
+	ErrVersionConflict   = errors.New("transaction was modified concurrently")
+)
+
+/*
+allowedTransitions is the transaction state machine.
+
+PENDING is the only entry state. The evaluator moves it to a decision
+(SUCCESS / FLAGGED / BLOCKED) and the stale job gives up on it (FAILED).
+FLAGGED can still be resolved by a reviewer. Money that moved can come
+back as a refund, a reversal or a chargeback, and a refunded or reversed
+payment can still be disputed. Everything else is terminal.
+*/
+var allowedTransitions = map[string][]string{
+	StatusPending:  {StatusSuccess, StatusFlagged, StatusBlocked, StatusFailed},
+	StatusFlagged:  {StatusSuccess, StatusBlocked, StatusChargeback},
+	StatusSuccess:  {StatusRefunded, StatusReversed, StatusChargeback},
+	StatusRefunded: {StatusChargeback},
+	StatusReversed: {StatusChargeback},
+}
+
+// CanTransition reports whether a transaction may move from one status to another.
+func CanTransition(from, to string) bool {
+	for _, s := range allowedTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
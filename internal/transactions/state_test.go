@@ -0,0 +1,37 @@
+package transactions
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusPending, StatusSuccess, true},
+		{StatusPending, StatusFlagged, true},
+		{StatusPending, StatusBlocked, true},
+		{StatusPending, StatusFailed, true},
+		{StatusPending, StatusRefunded, false},
+		{StatusFlagged, StatusSuccess, true},
+		{StatusFlagged, StatusBlocked, true},
+		{StatusFlagged, StatusChargeback, true},
+		{StatusFlagged, StatusPending, false},
+		{StatusSuccess, StatusRefunded, true},
+		{StatusSuccess, StatusReversed, true},
+		{StatusSuccess, StatusChargeback, true},
+		{StatusSuccess, StatusBlocked, false},
+		{StatusRefunded, StatusChargeback, true},
+		{StatusReversed, StatusChargeback, true},
+		{StatusRefunded, StatusSuccess, false},
+		{StatusBlocked, StatusSuccess, false},
+		{StatusFailed, StatusPending, false},
+		{StatusChargeback, StatusRefunded, false},
+		{"UNKNOWN", StatusSuccess, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package transactions
+
+import "time"
+
+// TransactionTransition records every status change a transaction goes through.
+type TransactionTransition struct {
+	ID            string `gorm:"primaryKey"`
+	TransactionID string `gorm:"index"`
+	FromStatus    string
+	ToStatus      string
+	Version       int
+	Actor         string
+	Reason        string
+	CreatedAt     time.Time
+}
@@ -0,0 +1,136 @@
+package users
+
+import (
+	"errors"
+
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /users/me
+func GetProfileHandler(c *gin.Context) {
+	profile, err := GetProfile(c.GetString("user_id"))
+	if errors.Is(err, ErrUserNotFound) {
+		response.Error(c, 404, "User not found", nil)
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to fetch profile", err.Error())
+		return
+	}
+	response.Success(c, "Profile fetched", profile)
+}
+
+// PATCH /users/me
+func UpdateProfileHandler(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	profile, err := UpdateProfile(c.GetString("user_id"), req.Name)
+	if errors.Is(err, auth.ErrInvalidName) {
+		response.Error(c, 400, "Failed to update profile", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to update profile", err.Error())
+		return
+	}
+	response.Success(c, "Profile updated", profile)
+}
+
+// PUT /users/me/password
+func ChangePasswordHandler(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	err := auth.ChangePassword(c.GetString("user_id"), c.GetString("session_id"), req.CurrentPassword, req.NewPassword)
+	var policy *auth.PasswordPolicyError
+	if errors.As(err, &policy) {
+		response.Error(c, 400, "Failed to change password", err.Error())
+		return
+	}
+	if errors.Is(err, auth.ErrWrongPassword) {
+		response.Error(c, 401, "Failed to change password", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to change password", err.Error())
+		return
+	}
+	response.Success(c, "Password changed, other sessions signed out", nil)
+}
+
+// PUT /users/me/email
+func ChangeEmailHandler(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	user, err := auth.ChangeEmail(c.GetString("user_id"), req.Password, req.Email)
+	if errors.Is(err, auth.ErrInvalidEmail) || errors.Is(err, auth.ErrEmailUnchanged) {
+		response.Error(c, 400, "Failed to change email", err.Error())
+		return
+	}
+	if errors.Is(err, auth.ErrWrongPassword) {
+		response.Error(c, 401, "Failed to change email", err.Error())
+		return
+	}
+	if errors.Is(err, auth.ErrEmailTaken) {
+		response.Error(c, 409, "Failed to change email", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to change email", err.Error())
+		return
+	}
+	response.Success(c, "Open the link sent to the new address to finish the change", gin.H{
+		"email":         user.Email,
+		"pending_email": user.PendingEmail,
+	})
+}
+
+// DELETE /users/me
+func DeleteAccountHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, 400, "Invalid request", err.Error())
+		return
+	}
+
+	err := DeleteAccount(c.GetString("user_id"), req.Password)
+	if errors.Is(err, auth.ErrWrongPassword) {
+		response.Error(c, 401, "Failed to delete account", err.Error())
+		return
+	}
+	if errors.Is(err, ErrLastAdminAccount) {
+		response.Error(c, 409, "Failed to delete account", err.Error())
+		return
+	}
+	if err != nil {
+		response.Error(c, 500, "Failed to delete account", err.Error())
+		return
+	}
+
+	auth.ClearAuthCookies(c)
+	response.Success(c, "Account deleted", nil)
+}
@@ -0,0 +1,27 @@
+package users
+
+import "time"
+
+// Profile is what a user sees of their own account, and what admins see of anyone's.
+type Profile struct {
+	ID              string     `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PendingEmail    string     `json:"pending_email,omitempty"`
+	Role            string     `json:"role"`
+	Status          string     `json:"status"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// UserFilter narrows the admin user list. Query matches name, email or exact ID.
+type UserFilter struct {
+	Query  string
+	Status string
+	Role   string
+	Limit  int
+	Offset int
+}
@@ -1 +1,119 @@
 package users
+
+import (
+	"time"
+
+	"fraud-detection-backend/internal/auth"
+	"fraud-detection-backend/internal/database"
+	"fraud-detection-backend/internal/notifications"
+
+	"gorm.io/gorm"
+)
+
+const profileColumns = `users.id, users.name, users.email, users.email_verified_at, users.pending_email, users.role,
+	users.status, users.status_reason, users.status_changed_at, users.created_at, mfa_factors.confirmed_at IS NOT NULL AS mfa_enabled`
+
+func FindProfile(userID string) (*Profile, error) {
+	var profile Profile
+	res := database.DB.
+		Table("users").
+		Select(profileColumns).
+		Joins("LEFT JOIN mfa_factors ON mfa_factors.user_id = users.id").
+		Where("users.id = ?", userID).
+		Limit(1).
+		Scan(&profile)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrUserNotFound
+	}
+	return &profile, nil
+}
+
+func SearchProfiles(f UserFilter) ([]Profile, error) {
+	q := database.DB.
+		Table("users").
+		Select(profileColumns).
+		Joins("LEFT JOIN mfa_factors ON mfa_factors.user_id = users.id")
+
+	if f.Query != "" {
+		like := "%" + f.Query + "%"
+		q = q.Where("users.name ILIKE ? OR users.email ILIKE ? OR users.id::text = ?", like, like, f.Query)
+	}
+	if f.Status != "" {
+		q = q.Where("users.status = ?", f.Status)
+	}
+	if f.Role != "" {
+		q = q.Where("users.role = ?", f.Role)
+	}
+
+	var profiles []Profile
+	err := q.
+		Order("users.created_at DESC").
+		Limit(f.Limit).
+		Offset(f.Offset).
+		Scan(&profiles).Error
+	return profiles, err
+}
+
+func UpdateName(userID, name string) error {
+	return database.DB.Model(&auth.User{}).Where("id = ?", userID).Update("name", name).Error
+}
+
+func CountActiveAdmins() (int64, error) {
+	var count int64
+	err := database.DB.Model(&auth.User{}).
+		Where("role = ? AND status = ?", auth.RoleAdmin, auth.UserActive).
+		Count(&count).Error
+	return count, err
+}
+
+/*
+AnonymizeUser strips personal data from a deleted account in one
+transaction. Transactions, fraud evaluations, login attempts and audit
+logs stay, keyed by the now anonymous user ID, because fraud and
+chargeback records must be kept after the customer leaves. Data that
+only served the user (notifications, MFA, email links) is dropped.
+*/
+func AnonymizeUser(userID string, now time.Time) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&auth.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"name":              "Deleted user",
+			"email":             "deleted-" + userID + "@deleted.invalid",
+			"password":          "",
+			"role":              auth.RoleUser,
+			"status":            auth.UserDeleted,
+			"status_reason":     "",
+			"status_changed_at": now,
+			"email_verified_at": nil,
+			"pending_email":     "",
+			"deleted_at":        now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&auth.LoginAttempt{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"email":      "",
+			"user_agent": "",
+			"latitude":   nil,
+			"longitude":  nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&auth.MFAFactor{},
+			&auth.RecoveryCode{},
+			&auth.UserToken{},
+			&notifications.Notification{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,153 @@
+package users
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/auth"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrOwnStatusChange    = errors.New("you cannot change your own status")
+	ErrAccountDeleted     = errors.New("account has been deleted")
+	ErrLastAdminAccount   = errors.New("the last admin cannot be restricted, suspended or deleted")
+	ErrInvalidStatusQuery = errors.New("unknown status")
+)
+
+func GetProfile(userID string) (*Profile, error) {
+	return FindProfile(userID)
+}
+
+// UpdateProfile changes what a user may edit freely; email and password have their own flows.
+func UpdateProfile(userID, name string) (*Profile, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, auth.ErrInvalidName
+	}
+	if err := UpdateName(userID, name); err != nil {
+		return nil, err
+	}
+	return FindProfile(userID)
+}
+
+/*
+DeleteAccount closes the user's own account after checking the
+password. The account row is kept but anonymized, see AnonymizeUser.
+*/
+func DeleteAccount(userID, password string) error {
+	user, err := auth.CheckPassword(userID, password)
+	if err != nil {
+		return err
+	}
+	if err := checkNotLastAdmin(user); err != nil {
+		return err
+	}
+
+	if _, err := auth.ForceLogout(user.ID, "account deleted", user.ID); err != nil {
+		return err
+	}
+	if err := AnonymizeUser(user.ID, time.Now()); err != nil {
+		return err
+	}
+	auth.ClearAccountThrottle(user.Email)
+
+	logUserChange("ACCOUNT_DELETED", user.ID, "Account deleted and anonymized by the user")
+	log.Println("🗑️ Account deleted:", user.ID)
+	return nil
+}
+
+func ListUsers(f UserFilter) ([]Profile, error) {
+	switch f.Status {
+	case "", auth.UserActive, auth.UserRestricted, auth.UserSuspended, auth.UserDeleted:
+	default:
+		return nil, ErrInvalidStatusQuery
+	}
+	if f.Limit <= 0 || f.Limit > 100 {
+		f.Limit = 50
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	f.Query = strings.TrimSpace(f.Query)
+	return SearchProfiles(f)
+}
+
+/*
+SetUserStatus applies or lifts a restriction or suspension. Suspending
+freezes a compromised account at once; restricting lets the user sign
+in and see their data but not make transactions.
+*/
+func SetUserStatus(userID, status, reason, actor string) (*Profile, error) {
+	if userID == actor {
+		return nil, ErrOwnStatusChange
+	}
+	user, err := auth.FindUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Status == auth.UserDeleted {
+		return nil, ErrAccountDeleted
+	}
+
+	// The last-admin check runs under the same locks as the change itself.
+	_, err = auth.ChangeUserStatus(user.ID, status, reason, actor)
+	if errors.Is(err, auth.ErrLastAdmin) {
+		return nil, ErrLastAdminAccount
+	}
+	if err != nil {
+		return nil, err
+	}
+	return FindProfile(user.ID)
+}
+
+func SuspendUser(userID, reason, actor string) (*Profile, error) {
+	return SetUserStatus(userID, auth.UserSuspended, reason, actor)
+}
+
+func RestrictUser(userID, reason, actor string) (*Profile, error) {
+	return SetUserStatus(userID, auth.UserRestricted, reason, actor)
+}
+
+// ReactivateUser lifts a restriction or a suspension.
+func ReactivateUser(userID, reason, actor string) (*Profile, error) {
+	return SetUserStatus(userID, auth.UserActive, reason, actor)
+}
+
+func GetStatusHistory(userID string) ([]auth.UserStatusChange, error) {
+	return auth.GetUserStatusHistory(userID)
+}
+
+func checkNotLastAdmin(user *auth.User) error {
+	if user.Role != auth.RoleAdmin {
+		return nil
+	}
+	admins, err := CountActiveAdmins()
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastAdminAccount
+	}
+	return nil
+}
+
+func logUserChange(eventType, userID, description string) {
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   eventType,
+		EntityType:  "USER",
+		EntityID:    userID,
+		Description: description,
+		CreatedAt:   time.Now(),
+	})
+}
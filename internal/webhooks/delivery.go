@@ -0,0 +1,246 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"fraud-detection-backend/internal/events"
+
+	"github.com/google/uuid"
+)
+
+const (
+	deliveryPollInterval = 2 * time.Second
+	deliveryBatchSize    = 20
+	deliveryTimeout      = 10 * time.Second
+	maxDeliveryAttempts  = 8
+	baseDeliveryBackoff  = 30 * time.Second
+	maxDeliveryBackoff   = 6 * time.Hour
+
+	// deliveryLease outlasts a whole batch of deliveries timing out, so
+	// no other worker claims a row this one is still sending.
+	deliveryLease = deliveryBatchSize*deliveryTimeout + time.Minute
+)
+
+var (
+	httpClient = &http.Client{
+		Timeout: deliveryTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: deliveryTimeout,
+				Control: dialPublic,
+			}).DialContext,
+			TLSHandshakeTimeout: deliveryTimeout,
+		},
+		// A redirect is answered as is and counts as a failed delivery.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	workerStarted bool
+	workerStop    = make(chan struct{})
+	workerDone    = make(chan struct{})
+)
+
+// partnerJSON is env with its payload swapped for the partner view of decision.
+func partnerJSON(env *events.Envelope, decision events.DecisionEvent) (string, error) {
+	payload, err := json.Marshal(partnerDecision{
+		TransactionID: decision.TransactionID,
+		MerchantID:    decision.MerchantID,
+		Status:        decision.Status,
+		EvaluatedAt:   decision.EvaluatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	out := *env
+	out.Payload = payload
+	b, err := json.Marshal(out)
+	return string(b), err
+}
+
+/*
+dialPublic refuses to connect to anything but a public address. It runs
+on the resolved address, so a partner hostname that later resolves into
+our network (or a redirect to one) is still refused.
+*/
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return ErrPrivateURL
+	}
+	return nil
+}
+
+/*
+Sign returns the X-Webhook-Signature value for body.
+
+Partners recompute HMAC-SHA256(secret, timestamp + "." + body) and
+compare; the timestamp lets them reject old replays.
+*/
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// StartDeliveryWorker sends pending deliveries in the background.
+func StartDeliveryWorker() {
+	workerStarted = true
+
+	go func() {
+		defer close(workerDone)
+
+		ticker := time.NewTicker(deliveryPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				deliverDue()
+			case <-workerStop:
+				return
+			}
+		}
+	}()
+
+	log.Println("🪝 Webhook delivery worker started")
+}
+
+// StopDeliveryWorker lets the batch in progress finish, then stops the worker.
+func StopDeliveryWorker(ctx context.Context) {
+	if !workerStarted {
+		return
+	}
+	close(workerStop)
+
+	select {
+	case <-workerDone:
+	case <-ctx.Done():
+	}
+}
+
+func deliverDue() {
+	due, err := ClaimDueDeliveries(deliveryBatchSize, deliveryLease)
+	if err != nil {
+		log.Println("❌ Webhook delivery claim failed:", err)
+		return
+	}
+
+	for i := range due {
+		attemptDelivery(&due[i])
+	}
+}
+
+func attemptDelivery(d *Delivery) {
+	attempt := d.Attempts + 1
+
+	endpoint, err := FindEndpoint(d.EndpointID)
+	if err != nil || !endpoint.Active {
+		RecordAttempt(&DeliveryAttempt{
+			ID:         uuid.NewString(),
+			DeliveryID: d.ID,
+			Attempt:    attempt,
+			Error:      "endpoint removed or inactive",
+			CreatedAt:  time.Now(),
+		}, map[string]interface{}{
+			"status":     DeliveryFailed,
+			"attempts":   attempt,
+			"last_error": "endpoint removed or inactive",
+		})
+		return
+	}
+
+	start := time.Now()
+	status, sendErr := send(endpoint, d)
+	duration := time.Since(start)
+
+	record := &DeliveryAttempt{
+		ID:             uuid.NewString(),
+		DeliveryID:     d.ID,
+		Attempt:        attempt,
+		ResponseStatus: status,
+		DurationMs:     duration.Milliseconds(),
+		CreatedAt:      time.Now(),
+	}
+	updates := map[string]interface{}{
+		"attempts":        attempt,
+		"response_status": status,
+	}
+
+	switch {
+	case sendErr == nil:
+		now := time.Now()
+		updates["status"] = DeliverySucceeded
+		updates["delivered_at"] = &now
+		updates["last_error"] = ""
+	case attempt >= maxDeliveryAttempts:
+		record.Error = sendErr.Error()
+		updates["status"] = DeliveryFailed
+		updates["last_error"] = sendErr.Error()
+		log.Println("☠️ Webhook delivery gave up:", d.ID, sendErr)
+	default:
+		record.Error = sendErr.Error()
+		updates["last_error"] = sendErr.Error()
+		updates["next_attempt_at"] = time.Now().Add(deliveryBackoff(attempt))
+	}
+
+	if err := RecordAttempt(record, updates); err != nil {
+		log.Println("❌ Failed to record webhook attempt:", d.ID, err)
+	}
+}
+
+func send(endpoint *Endpoint, d *Delivery) (int, error) {
+	body := []byte(d.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Id", d.ID)
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", Sign(endpoint.Secret, timestamp, body))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// deliveryBackoff doubles from 30s per attempt, capped at 6h.
+func deliveryBackoff(attempt int) time.Duration {
+	backoff := baseDeliveryBackoff << uint(attempt-1)
+	if backoff <= 0 || backoff > maxDeliveryBackoff {
+		return maxDeliveryBackoff
+	}
+	return backoff
+}
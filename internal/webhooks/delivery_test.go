@@ -0,0 +1,81 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"fraud-detection-backend/internal/events"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event_id":"e1"}`)
+
+	// HMAC-SHA256("whsec_test", "1700000000." + body), computed independently.
+	want := "sha256=8a9b910184f1d9ed3590e106c991e230a182f3b9356c7c1ed91c013d17ed0775"
+	if got := Sign("whsec_test", 1700000000, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+
+	if Sign("whsec_test", 1700000001, body) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+	if Sign("whsec_other", 1700000000, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestPartnerJSONLeavesOutRulesAndScore(t *testing.T) {
+	env := &events.Envelope{
+		EventID: "e1",
+		Type:    events.TransactionEvaluatedType,
+		Version: 1,
+	}
+	body, err := partnerJSON(env, events.DecisionEvent{
+		TransactionID: "t1",
+		UserID:        "u1",
+		MerchantID:    "m1",
+		Status:        "BLOCKED",
+		RiskScore:     90,
+		Rules:         []string{"HIGH_AMOUNT"},
+		EvaluatedAt:   time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("partnerJSON: %v", err)
+	}
+
+	for _, leaked := range []string{"risk_score", "rules", "HIGH_AMOUNT", "u1"} {
+		if strings.Contains(body, leaked) {
+			t.Errorf("partner payload contains %q: %s", leaked, body)
+		}
+	}
+
+	var got struct {
+		EventID string          `json:"event_id"`
+		Payload partnerDecision `json:"payload"`
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.EventID != "e1" || got.Payload.TransactionID != "t1" || got.Payload.MerchantID != "m1" || got.Payload.Status != "BLOCKED" {
+		t.Errorf("partner payload = %+v", got)
+	}
+}
+
+func TestDialPublic(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34:443":  true,
+		"127.0.0.1:443":      false,
+		"10.0.0.5:443":       false,
+		"169.254.169.254:80": false,
+		"[::1]:443":          false,
+		"[2606:4700::1]:443": true,
+	}
+
+	for address, allowed := range tests {
+		if err := dialPublic("tcp", address, nil); (err == nil) != allowed {
+			t.Errorf("dialPublic(%s) = %v, want allowed=%v", address, err, allowed)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/events"
+
+	"github.com/google/uuid"
+)
+
+const webhookQueue = "webhooks.transactions"
+
+/*
+partnerDecision is what a merchant is told about a decision. Rule names
+and scores stay internal: they would show how to get past the rules.
+*/
+type partnerDecision struct {
+	TransactionID string    `json:"transaction_id"`
+	MerchantID    string    `json:"merchant_id"`
+	Status        string    `json:"status"`
+	EvaluatedAt   time.Time `json:"evaluated_at"`
+}
+
+/*
+StartDispatcher binds a queue to the transaction events exchange and
+turns every event into one pending delivery per matching endpoint of
+the transaction's merchant.
+The HTTP calls happen later, in the delivery worker, so a slow partner
+never holds up the queue.
+*/
+func StartDispatcher() {
+	if err := events.DeclareQueue(events.QueueSpec{
+		Name: webhookQueue,
+		Bindings: []events.Binding{
+			{Exchange: events.TransactionEventsExchange, Pattern: "#"},
+		},
+	}); err != nil {
+		log.Fatal("Failed to declare webhook queue:", err)
+	}
+
+	if err := events.Subscribe(webhookQueue, "webhook-dispatcher", 16, dispatch); err != nil {
+		log.Fatal("Failed to start webhook dispatcher:", err)
+	}
+}
+
+func dispatch(d events.Delivery) {
+	env, err := events.DecodeEnvelope(d.Body, events.TransactionEvaluatedType)
+	if err != nil {
+		// Nothing a partner could do with it either.
+		log.Println("⚠️ Webhook dispatcher dropped invalid event:", err)
+		d.Ack()
+		return
+	}
+
+	if env.Type != events.TransactionEvaluatedType {
+		log.Println("⚠️ Webhook dispatcher dropped unsupported event:", env.Type)
+		d.Ack()
+		return
+	}
+
+	var decision events.DecisionEvent
+	if err := json.Unmarshal(env.Payload, &decision); err != nil {
+		log.Println("⚠️ Webhook dispatcher dropped invalid event:", err)
+		d.Ack()
+		return
+	}
+
+	// Events from before endpoints were scoped carry no merchant; nobody gets them.
+	if decision.MerchantID == "" {
+		d.Ack()
+		return
+	}
+
+	endpoints, err := ListActiveEndpoints(decision.MerchantID)
+	if err != nil {
+		log.Println("❌ Webhook dispatcher failed to load endpoints:", err)
+		d.Nack(true)
+		return
+	}
+
+	// Partners get our envelope around the partner view of the decision.
+	body, err := partnerJSON(env, decision)
+	if err != nil {
+		log.Println("⚠️ Webhook dispatcher failed to encode event:", err)
+		d.Ack()
+		return
+	}
+
+	for _, e := range endpoints {
+		if !matchesFilters(e.EventFilters, d.RoutingKey) {
+			continue
+		}
+
+		if err := CreateDelivery(&Delivery{
+			ID:            uuid.NewString(),
+			EndpointID:    e.ID,
+			EventID:       env.EventID,
+			EventType:     env.Type,
+			RoutingKey:    d.RoutingKey,
+			Payload:       body,
+			Status:        DeliveryPending,
+			NextAttemptAt: time.Now(),
+			CreatedAt:     time.Now(),
+		}); err != nil {
+			log.Println("❌ Failed to queue webhook delivery:", e.ID, err)
+			d.Nack(true)
+			return
+		}
+	}
+
+	d.Ack()
+}
+
+func matchesFilters(filters, routingKey string) bool {
+	for _, pattern := range strings.Split(filters, ",") {
+		if events.TopicMatches(strings.TrimSpace(pattern), routingKey) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import "time"
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "PENDING"
+	DeliverySucceeded = "SUCCEEDED"
+	DeliveryFailed    = "FAILED"
+)
+
+/*
+Endpoint is a partner URL that receives our events.
+
+EventFilters is a comma-separated list of routing-key patterns, e.g.
+"transactions.evaluated.blocked,transactions.evaluated.flagged" or
+"transactions.evaluated.#". An endpoint belongs to one merchant and
+only receives events for that merchant's transactions. Secret signs
+every payload and is only shown once, when the endpoint is created.
+*/
+type Endpoint struct {
+	ID           string    `gorm:"primaryKey" json:"id"`
+	MerchantID   string    `gorm:"not null;default:'';index" json:"merchant_id"`
+	Name         string    `json:"name"`
+	URL          string    `gorm:"not null" json:"url"`
+	Secret       string    `gorm:"not null" json:"-"`
+	EventFilters string    `gorm:"not null" json:"event_filters"`
+	Active       bool      `gorm:"not null;default:true" json:"active"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Delivery is one event owed to one endpoint. EventID is unique per endpoint, so redelivered events are not sent twice.
+type Delivery struct {
+	ID             string     `gorm:"primaryKey" json:"id"`
+	EndpointID     string     `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"endpoint_id"`
+	EventID        string     `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"event_id"`
+	EventType      string     `json:"event_type"`
+	RoutingKey     string     `json:"routing_key"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"size:20;not null;index" json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `gorm:"index" json:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+// DeliveryAttempt is the delivery log: one row per HTTP call made.
+type DeliveryAttempt struct {
+	ID             string    `gorm:"primaryKey" json:"id"`
+	DeliveryID     string    `gorm:"index;not null" json:"delivery_id"`
+	Attempt        int       `json:"attempt"`
+	ResponseStatus int       `json:"response_status"`
+	Error          string    `json:"error"`
+	DurationMs     int64     `json:"duration_ms"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,134 @@
+package webhooks
+
+import (
+	"time"
+
+	"fraud-detection-backend/internal/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func CreateEndpoint(e *Endpoint) error {
+	return database.DB.Create(e).Error
+}
+
+func FindEndpoint(id string) (*Endpoint, error) {
+	var e Endpoint
+	err := database.DB.First(&e, "id = ?", id).Error
+	return &e, err
+}
+
+func ListEndpoints() ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := database.DB.Order("created_at DESC").Find(&endpoints).Error
+	return endpoints, err
+}
+
+func ListActiveEndpoints(merchantID string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	err := database.DB.
+		Where("active = ? AND merchant_id = ?", true, merchantID).
+		Find(&endpoints).Error
+	return endpoints, err
+}
+
+func UpdateEndpoint(id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return database.DB.Model(&Endpoint{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func DeleteEndpoint(id string) error {
+	return database.DB.Delete(&Endpoint{}, "id = ?", id).Error
+}
+
+// CreateDelivery ignores an event that was already queued for the endpoint.
+func CreateDelivery(d *Delivery) error {
+	return database.DB.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(d).Error
+}
+
+func FindDelivery(id string) (*Delivery, error) {
+	var d Delivery
+	err := database.DB.First(&d, "id = ?", id).Error
+	return &d, err
+}
+
+func GetEndpointDeliveries(endpointID, status string, limit, offset int) ([]Delivery, error) {
+	var deliveries []Delivery
+	q := database.DB.
+		Where("endpoint_id = ?", endpointID).
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	err := q.Find(&deliveries).Error
+	return deliveries, err
+}
+
+func GetDeliveryAttempts(deliveryID string) ([]DeliveryAttempt, error) {
+	var attempts []DeliveryAttempt
+	err := database.DB.
+		Where("delivery_id = ?", deliveryID).
+		Order("attempt ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+/*
+ClaimDueDeliveries takes a lease on due deliveries by pushing their
+next attempt out, so HTTP calls happen outside the DB transaction and
+another instance will not pick the same rows meanwhile.
+*/
+func ClaimDueDeliveries(limit int, lease time.Duration) ([]Delivery, error) {
+	var due []Delivery
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", DeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").
+			Limit(limit).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(due))
+		for i, d := range due {
+			ids[i] = d.ID
+		}
+
+		return tx.Model(&Delivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+
+	return due, err
+}
+
+func RecordAttempt(attempt *DeliveryAttempt, updates map[string]interface{}) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Delivery{}).Where("id = ?", attempt.DeliveryID).Updates(updates).Error
+	})
+}
+
+func ResetDelivery(id string) error {
+	return database.DB.
+		Model(&Delivery{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"last_error":      "",
+		}).Error
+}
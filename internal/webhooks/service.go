@@ -0,0 +1,257 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"fraud-detection-backend/internal/audit"
+	"fraud-detection-backend/internal/merchants"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidURL      = errors.New("url must be an absolute https URL")
+	ErrPrivateURL      = fmt.Errorf("%w on a public address", ErrInvalidURL)
+	ErrInvalidFilters  = errors.New("event_filters must list at least one routing-key pattern")
+	ErrInvalidMerchant = errors.New("merchant_id must name an existing merchant")
+)
+
+// lookupIP is replaced in tests so they do not depend on DNS.
+var lookupIP = net.LookupIP
+
+type EndpointInput struct {
+	MerchantID   string   `json:"merchant_id"`
+	Name         string   `json:"name"`
+	URL          string   `json:"url"`
+	EventFilters []string `json:"event_filters"`
+	Active       *bool    `json:"active"`
+}
+
+/*
+validateURL only accepts https URLs whose host resolves to public
+addresses, so an endpoint cannot be pointed at our own network. The
+delivery dialer checks the address again at connect time, in case the
+name resolves differently later.
+*/
+func validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateURL
+	}
+
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		if ips, err = lookupIP(host); err != nil || len(ips) == 0 {
+			return ErrInvalidURL
+		}
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return ErrPrivateURL
+		}
+	}
+	return nil
+}
+
+// carrierNAT is the shared address space (RFC 6598), private in practice.
+var carrierNAT = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!carrierNAT.Contains(ip)
+}
+
+func validateMerchant(id string) error {
+	if id == "" || id == merchants.UnknownMerchantID {
+		return ErrInvalidMerchant
+	}
+	if _, err := merchants.FindByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidMerchant
+		}
+		return err
+	}
+	return nil
+}
+
+func joinFilters(filters []string) (string, error) {
+	var cleaned []string
+	for _, f := range filters {
+		if f = strings.TrimSpace(f); f != "" {
+			cleaned = append(cleaned, f)
+		}
+	}
+	if len(cleaned) == 0 {
+		return "", ErrInvalidFilters
+	}
+	return strings.Join(cleaned, ","), nil
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// RegisterEndpoint creates an endpoint and returns its signing secret; the secret is not retrievable later.
+func RegisterEndpoint(in EndpointInput, actor string) (*Endpoint, string, error) {
+	if err := validateMerchant(in.MerchantID); err != nil {
+		return nil, "", err
+	}
+	if err := validateURL(in.URL); err != nil {
+		return nil, "", err
+	}
+	filters, err := joinFilters(in.EventFilters)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, "", err
+	}
+
+	e := &Endpoint{
+		ID:           uuid.NewString(),
+		MerchantID:   in.MerchantID,
+		Name:         in.Name,
+		URL:          in.URL,
+		Secret:       secret,
+		EventFilters: filters,
+		Active:       in.Active == nil || *in.Active,
+		CreatedBy:    actor,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := CreateEndpoint(e); err != nil {
+		return nil, "", err
+	}
+
+	logEndpointChange("WEBHOOK_ENDPOINT_CREATED", e.ID, actor, e.URL)
+	return e, secret, nil
+}
+
+// ChangeEndpoint updates the fields present in in.
+func ChangeEndpoint(id string, in EndpointInput, actor string) (*Endpoint, error) {
+	if _, err := FindEndpoint(id); err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if in.MerchantID != "" {
+		if err := validateMerchant(in.MerchantID); err != nil {
+			return nil, err
+		}
+		updates["merchant_id"] = in.MerchantID
+	}
+	if in.Name != "" {
+		updates["name"] = in.Name
+	}
+	if in.URL != "" {
+		if err := validateURL(in.URL); err != nil {
+			return nil, err
+		}
+		updates["url"] = in.URL
+	}
+	if in.EventFilters != nil {
+		filters, err := joinFilters(in.EventFilters)
+		if err != nil {
+			return nil, err
+		}
+		updates["event_filters"] = filters
+	}
+	if in.Active != nil {
+		updates["active"] = *in.Active
+	}
+
+	if err := UpdateEndpoint(id, updates); err != nil {
+		return nil, err
+	}
+
+	logEndpointChange("WEBHOOK_ENDPOINT_UPDATED", id, actor, "")
+	return FindEndpoint(id)
+}
+
+func RemoveEndpoint(id, actor string) error {
+	if _, err := FindEndpoint(id); err != nil {
+		return err
+	}
+	if err := DeleteEndpoint(id); err != nil {
+		return err
+	}
+
+	logEndpointChange("WEBHOOK_ENDPOINT_DELETED", id, actor, "")
+	return nil
+}
+
+func logEndpointChange(eventType, id, actor, detail string) {
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   eventType,
+		EntityType:  "WEBHOOK_ENDPOINT",
+		EntityID:    id,
+		Description: strings.TrimSpace("By " + actor + " " + detail),
+		CreatedAt:   time.Now(),
+	})
+}
+
+func FetchEndpoints() ([]Endpoint, error) {
+	return ListEndpoints()
+}
+
+func FetchDeliveries(endpointID, status string, limit, offset int) ([]Delivery, error) {
+	return GetEndpointDeliveries(endpointID, strings.ToUpper(status), limit, offset)
+}
+
+type DeliveryDetail struct {
+	Delivery *Delivery         `json:"delivery"`
+	Attempts []DeliveryAttempt `json:"attempts"`
+}
+
+func FetchDeliveryDetail(id string) (*DeliveryDetail, error) {
+	d, err := FindDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	attempts, err := GetDeliveryAttempts(id)
+	if err != nil {
+		return nil, err
+	}
+	return &DeliveryDetail{Delivery: d, Attempts: attempts}, nil
+}
+
+// Redeliver queues a delivery again right away, with a fresh retry budget.
+func Redeliver(id, actor string) (*Delivery, error) {
+	if _, err := FindDelivery(id); err != nil {
+		return nil, err
+	}
+	if err := ResetDelivery(id); err != nil {
+		return nil, err
+	}
+
+	audit.CreateLog(&audit.AuditLog{
+		ID:          uuid.NewString(),
+		EventType:   "WEBHOOK_REDELIVERY_REQUESTED",
+		EntityType:  "WEBHOOK_DELIVERY",
+		EntityID:    id,
+		Description: "By " + actor,
+		CreatedAt:   time.Now(),
+	})
+
+	return FindDelivery(id)
+}
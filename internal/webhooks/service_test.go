@@ -0,0 +1,55 @@
+package webhooks
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "partner.example":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "internal.example":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.1.2.3")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	t.Cleanup(func() { lookupIP = net.LookupIP })
+
+	tests := []struct {
+		url  string
+		want error
+	}{
+		{"https://partner.example/hooks", nil},
+		{"https://93.184.216.34/hooks", nil},
+		{"http://partner.example/hooks", ErrInvalidURL},
+		{"ftp://partner.example/hooks", ErrInvalidURL},
+		{"/hooks", ErrInvalidURL},
+		{"https://unknown.example/hooks", ErrInvalidURL},
+		{"https://localhost/hooks", ErrPrivateURL},
+		{"https://api.localhost/hooks", ErrPrivateURL},
+		{"https://127.0.0.1/hooks", ErrPrivateURL},
+		{"https://[::1]/hooks", ErrPrivateURL},
+		{"https://10.0.0.1/hooks", ErrPrivateURL},
+		{"https://192.168.1.10/hooks", ErrPrivateURL},
+		{"https://169.254.169.254/latest", ErrPrivateURL},
+		{"https://100.64.0.1/hooks", ErrPrivateURL},
+		{"https://0.0.0.0/hooks", ErrPrivateURL},
+		{"https://internal.example/hooks", ErrPrivateURL},
+	}
+
+	for _, tt := range tests {
+		err := validateURL(tt.url)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("validateURL(%s) = %v, want nil", tt.url, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) {
+			t.Errorf("validateURL(%s) = %v, want %v", tt.url, err, tt.want)
+		}
+	}
+}